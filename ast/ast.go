@@ -1,8 +1,12 @@
 package ast
 
+import "github.com/eyanshu1997/yacgo/tokens"
+
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() tokens.Position // first character of the node
+	End() tokens.Position // just after the last character of the node
 }
 
 type Statement interface {
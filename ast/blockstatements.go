@@ -9,10 +9,21 @@ import (
 type BlockStatement struct {
 	Token      tokens.Token // the { token
 	Statements []Statement
+	RBrace     tokens.Token // the } token
 }
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() tokens.Position { return bs.Token.Start }
+func (bs *BlockStatement) End() tokens.Position {
+	if bs.RBrace.End.IsValid() {
+		return bs.RBrace.End
+	}
+	if len(bs.Statements) > 0 {
+		return bs.Statements[len(bs.Statements)-1].End()
+	}
+	return bs.Token.End
+}
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 	for _, s := range bs.Statements {
@@ -0,0 +1,142 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+// a // or /* */ comment, it is not part of the tree, see CommentMap
+type Comment struct {
+	Token tokens.Token // the tokens.TokenTypeComment token
+}
+
+func (c *Comment) TokenLiteral() string { return c.Token.Literal }
+func (c *Comment) Pos() tokens.Position { return c.Token.Start }
+func (c *Comment) End() tokens.Position { return c.Token.End }
+func (c *Comment) String() string       { return c.Token.Literal }
+
+// IsLine reports whether c is a // comment, which runs to the end of its line
+func (c *Comment) IsLine() bool {
+	return strings.HasPrefix(c.Token.Literal, "//")
+}
+
+// Text is the comment without its markers and surrounding spaces
+func (c *Comment) Text() string {
+	text := c.Token.Literal
+	if c.IsLine() {
+		text = strings.TrimPrefix(text, "//")
+	} else {
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	}
+	return strings.TrimSpace(text)
+}
+
+// CommentMap maps a node to the comments associated with it, in source order.
+// most comments belong to a statement, the ones inside an empty block or inside
+// an array or hash literal belong to the block or literal
+type CommentMap map[Node][]*Comment
+
+// NewCommentMap associates every comment with the nearest statement of the
+// innermost block holding it:
+//   - a comment after a statement on the line the statement ends on belongs to that statement
+//   - otherwise it belongs to the statement that follows it
+//   - otherwise, at the end of a block, to the statement before it
+//
+// a comment inside an empty block belongs to the block and one inside an array
+// or hash literal, but outside any block of it, to the innermost literal. any
+// other comment inside a statement belongs to that statement. comments are only
+// dropped when there is no statement at all
+func NewCommentMap(program *Program, comments []*Comment) CommentMap {
+	cmap := CommentMap{}
+	for _, c := range comments {
+		if n := nearestNode(program.Statements, c); n != nil {
+			cmap[n] = append(cmap[n], c)
+		}
+	}
+	return cmap
+}
+
+// Leading returns the comments of n that come before it
+func (cmap CommentMap) Leading(n Node) []*Comment {
+	var leading []*Comment
+	for _, c := range cmap[n] {
+		if c.End().Offset <= n.Pos().Offset {
+			leading = append(leading, c)
+		}
+	}
+	return leading
+}
+
+// Trailing returns the comments of n that are not before it
+func (cmap CommentMap) Trailing(n Node) []*Comment {
+	var trailing []*Comment
+	for _, c := range cmap[n] {
+		if c.End().Offset > n.Pos().Offset {
+			trailing = append(trailing, c)
+		}
+	}
+	return trailing
+}
+
+func nearestNode(list []Statement, c *Comment) Node {
+	var before, after Statement
+	for _, s := range list {
+		if s == nil {
+			continue
+		}
+		switch {
+		case s.Pos().Offset <= c.Pos().Offset && c.End().Offset <= s.End().Offset:
+			return nearestInside(s, c)
+		case s.End().Offset <= c.Pos().Offset:
+			before = s
+		case after == nil:
+			after = s
+		}
+	}
+	if before != nil && before.End().Line == c.Pos().Line {
+		return before
+	}
+	if after != nil {
+		return after
+	}
+	if before != nil {
+		return before
+	}
+	return nil
+}
+
+// the comment is inside n, look for a block or literal of n holding it
+func nearestInside(n Node, c *Comment) Node {
+	var holder Node
+	Inspect(n, func(m Node) bool {
+		if holder != nil || m == nil {
+			return false
+		}
+		if m != n && holds(m, c) {
+			holder = m
+			return false
+		}
+		return true
+	})
+	switch h := holder.(type) {
+	case nil:
+		return n
+	case *BlockStatement:
+		if inner := nearestNode(h.Statements, c); inner != nil {
+			return inner
+		}
+		return h
+	default:
+		return nearestInside(h, c)
+	}
+}
+
+// blocks and the literals that can be printed over several lines hold the comments inside them
+func holds(n Node, c *Comment) bool {
+	switch n.(type) {
+	case *BlockStatement, *ArrayLiteral, *HashLiteral:
+		return n.Pos().Offset < c.Pos().Offset && c.End().Offset <= n.End().Offset
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/parser"
+)
+
+func TestCommentMap(t *testing.T) {
+	input := `// leading a
+let a = 1; // trailing a
+fn f(x) {
+	// leading y
+	let y = x;
+	return y; /* trailing return */
+	// end of body
+}
+if (a) { /* inside empty block */ }
+let b = [1, /* inside expression */ 2];
+// end of file`
+	l := lexer.NewLexer(input, lexer.WithComments())
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(program.Comments) != 8 {
+		t.Fatalf("expected 8 comments, got=%d", len(program.Comments))
+	}
+
+	body := program.Statements[1].(*ast.FunctionStatement).Body.Statements
+	expected := []struct {
+		node     ast.Node
+		comments []string
+	}{
+		{program.Statements[0], []string{"// leading a", "// trailing a"}},
+		{program.Statements[1], nil},
+		{body[0], []string{"// leading y"}},
+		{body[1], []string{"/* trailing return */", "// end of body"}},
+		{program.Statements[2], nil},
+		{program.Statements[2].(*ast.IfStatement).Consequence, []string{"/* inside empty block */"}},
+		{program.Statements[3], []string{"// end of file"}},
+		{program.Statements[3].(*ast.LetStatement).Value, []string{"/* inside expression */"}},
+	}
+	for i, tt := range expected {
+		var got []string
+		for _, c := range program.CommentMap[tt.node] {
+			got = append(got, c.String())
+		}
+		if strings.Join(got, "|") != strings.Join(tt.comments, "|") {
+			t.Errorf("tests[%d] - wrong comments for %q. expected=%q, got=%q", i, tt.node, tt.comments, got)
+		}
+	}
+
+	leading := program.CommentMap.Leading(program.Statements[0])
+	trailing := program.CommentMap.Trailing(program.Statements[0])
+	if len(leading) != 1 || leading[0].Text() != "leading a" {
+		t.Errorf("wrong leading comments. got=%v", leading)
+	}
+	if len(trailing) != 1 || trailing[0].Text() != "trailing a" || !trailing[0].IsLine() {
+		t.Errorf("wrong trailing comments. got=%v", trailing)
+	}
+}
+
+func TestCommentMapWithoutStatements(t *testing.T) {
+	l := lexer.NewLexer("// only a comment", lexer.WithComments())
+	program := parser.NewParser(l).ParseProgram()
+	if len(program.Comments) != 1 || len(program.CommentMap) != 0 {
+		t.Errorf("expected one unattached comment. got=%v %v", program.Comments, program.CommentMap)
+	}
+}
@@ -1,6 +1,10 @@
 package ast
 
 import (
+	"bytes"
+	"strings"
+
+	"github.com/eyanshu1997/yacgo/common/utils"
 	"github.com/eyanshu1997/yacgo/tokens"
 )
 
@@ -12,6 +16,8 @@ type Boolean struct {
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) String() string       { return b.Token.Literal }
+func (b *Boolean) Pos() tokens.Position { return b.Token.Start }
+func (b *Boolean) End() tokens.Position { return b.Token.End }
 
 type IntegerLiteral struct {
 	Token tokens.Token
@@ -21,3 +27,87 @@ type IntegerLiteral struct {
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
+func (il *IntegerLiteral) Pos() tokens.Position { return il.Token.Start }
+func (il *IntegerLiteral) End() tokens.Position { return il.Token.End }
+
+type FloatLiteral struct {
+	Token tokens.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() tokens.Position { return fl.Token.Start }
+func (fl *FloatLiteral) End() tokens.Position { return fl.Token.End }
+
+type StringLiteral struct {
+	Token tokens.Token
+	Value string // decoded value, without quotes
+}
+
+func (sl *StringLiteral) expressionNode()      {}
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) String() string       { return utils.QuoteString(sl.Value) }
+func (sl *StringLiteral) Pos() tokens.Position { return sl.Token.Start }
+func (sl *StringLiteral) End() tokens.Position { return sl.Token.End }
+
+type ArrayLiteral struct {
+	Token    tokens.Token // the '[' token
+	Elements []Expression
+	RBracket tokens.Token // the ']' token
+}
+
+func (al *ArrayLiteral) expressionNode()      {}
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() tokens.Position { return al.Token.Start }
+func (al *ArrayLiteral) End() tokens.Position {
+	if al.RBracket.End.IsValid() {
+		return al.RBracket.End
+	}
+	return al.Token.End
+}
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// {"name": "yal"}, pairs are kept in source order
+type HashLiteral struct {
+	Token  tokens.Token // the '{' token
+	Pairs  []*HashPair
+	RBrace tokens.Token // the '}' token
+}
+
+func (hl *HashLiteral) expressionNode()      {}
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() tokens.Position { return hl.Token.Start }
+func (hl *HashLiteral) End() tokens.Position {
+	if hl.RBrace.End.IsValid() {
+		return hl.RBrace.End
+	}
+	return hl.Token.End
+}
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
@@ -16,6 +16,13 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() tokens.Position { return pe.Token.Start }
+func (pe *PrefixExpression) End() tokens.Position {
+	if pe.Right != nil {
+		return pe.Right.End()
+	}
+	return pe.Token.End
+}
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -34,6 +41,18 @@ type InfixExpression struct {
 
 func (oe *InfixExpression) expressionNode()      {}
 func (oe *InfixExpression) TokenLiteral() string { return oe.Token.Literal }
+func (oe *InfixExpression) Pos() tokens.Position {
+	if oe.Left != nil {
+		return oe.Left.Pos()
+	}
+	return oe.Token.Start
+}
+func (oe *InfixExpression) End() tokens.Position {
+	if oe.Right != nil {
+		return oe.Right.End()
+	}
+	return oe.Token.End
+}
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -48,10 +67,23 @@ type CallExpression struct {
 	Token     tokens.Token // The '(' token
 	Function  Expression   // Identifier or FunctionLiteral
 	Arguments []Expression
+	RParen    tokens.Token // the ')' token
 }
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() tokens.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Start
+}
+func (ce *CallExpression) End() tokens.Position {
+	if ce.RParen.End.IsValid() {
+		return ce.RParen.End
+	}
+	return ce.Token.End
+}
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 	args := []string{}
@@ -64,3 +96,52 @@ func (ce *CallExpression) String() string {
 	out.WriteString(")")
 	return out.String()
 }
+
+// arr[i]
+type IndexExpression struct {
+	Token    tokens.Token // The '[' token
+	Left     Expression
+	Index    Expression
+	RBracket tokens.Token // the ']' token
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() tokens.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Start
+}
+func (ie *IndexExpression) End() tokens.Position {
+	if ie.RBracket.End.IsValid() {
+		return ie.RBracket.End
+	}
+	return ie.Token.End
+}
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+	return out.String()
+}
+
+// BadExpression is a placeholder for an expression that could not be parsed
+type BadExpression struct {
+	Token tokens.Token    // first token of the broken expression
+	To    tokens.Position // end of the consumed source, the end of Token when not set
+}
+
+func (be *BadExpression) expressionNode()      {}
+func (be *BadExpression) TokenLiteral() string { return be.Token.Literal }
+func (be *BadExpression) String() string       { return "<bad expression>" }
+func (be *BadExpression) Pos() tokens.Position { return be.Token.Start }
+func (be *BadExpression) End() tokens.Position {
+	if be.To.IsValid() {
+		return be.To
+	}
+	return be.Token.End
+}
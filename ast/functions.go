@@ -7,24 +7,60 @@ import (
 	"github.com/eyanshu1997/yacgo/tokens"
 )
 
+// named declaration `fn add(a, b) { ... }`
 type FunctionStatement struct {
 	Token      tokens.Token // The 'fn' token
+	Name       *Identifier
 	Parameters []*Identifier
 	Body       *BlockStatement
 }
 
 func (fl *FunctionStatement) statementNode()       {}
 func (fl *FunctionStatement) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionStatement) Pos() tokens.Position { return fl.Token.Start }
+func (fl *FunctionStatement) End() tokens.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
 func (fl *FunctionStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(fl.TokenLiteral() + " ")
+	out.WriteString(fl.Name.String())
+	out.WriteString(functionSignature(fl.Parameters, fl.Body))
+	return out.String()
+}
+
+// anonymous function used as an expression `fn(a, b) { ... }`
+type FunctionLiteral struct {
+	Token      tokens.Token // The 'fn' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) expressionNode()      {}
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() tokens.Position { return fl.Token.Start }
+func (fl *FunctionLiteral) End() tokens.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
+func (fl *FunctionLiteral) String() string {
+	return fl.TokenLiteral() + functionSignature(fl.Parameters, fl.Body)
+}
+
+func functionSignature(parameters []*Identifier, body *BlockStatement) string {
 	var out bytes.Buffer
 	params := []string{}
-	for _, p := range fl.Parameters {
+	for _, p := range parameters {
 		params = append(params, p.String())
 	}
-	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
-	out.WriteString(fl.Body.String())
+	out.WriteString(body.String())
 	return out.String()
 }
@@ -3,11 +3,27 @@ package ast
 import "github.com/eyanshu1997/yacgo/tokens"
 
 type Identifier struct {
-	Token tokens.Token // token { identifier, literal}
-	Value string       // literal
+	Token   tokens.Token // token { identifier, literal}
+	Value   string       // literal
+	Binding *Binding     // where the name is declared, set by the resolver
 }
 
+// Binding locates the declaration of a name: Depth scopes out from the one the
+// name is used in, 0 being the innermost, at index Slot among the names declared
+// in that scope. a builtin has Depth -1 and its index in the builtins as Slot.
+// Captured is set on the binding of a declaration when a function declared in
+// the scope uses it, the compiler keeps such variables in cells
+type Binding struct {
+	Depth    int
+	Slot     int
+	Captured bool
+}
+
+func (b *Binding) IsBuiltin() bool { return b.Depth < 0 }
+
 // These will be used in ast
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) String() string       { return i.Value }
+func (i *Identifier) Pos() tokens.Position { return i.Token.Start }
+func (i *Identifier) End() tokens.Position { return i.Token.End }
@@ -6,6 +6,8 @@ import (
 	"github.com/eyanshu1997/yacgo/tokens"
 )
 
+// the Alternative of `if (a) {} else if (b) {}` is a block holding just the
+// second if statement, its Token is that if token and it has no RBrace
 type IfStatement struct {
 	Token       tokens.Token // The 'if' token
 	Condition   Expression
@@ -15,15 +17,54 @@ type IfStatement struct {
 
 func (ie *IfStatement) statementNode()       {}
 func (ie *IfStatement) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfStatement) Pos() tokens.Position { return ie.Token.Start }
+func (ie *IfStatement) End() tokens.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End
+}
 func (ie *IfStatement) String() string {
+	return ifString(ie.Condition, ie.Consequence, ie.Alternative)
+}
+
+// `let x = if (a) { 1 } else { 2 };`, the value is the value of the last
+// statement of the branch taken, null when no branch is taken
+type IfExpression struct {
+	Token       tokens.Token // The 'if' token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement
+}
+
+func (ie *IfExpression) expressionNode()      {}
+func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() tokens.Position { return ie.Token.Start }
+func (ie *IfExpression) End() tokens.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End
+}
+func (ie *IfExpression) String() string {
+	return ifString(ie.Condition, ie.Consequence, ie.Alternative)
+}
+
+func ifString(condition Expression, consequence, alternative *BlockStatement) string {
 	var out bytes.Buffer
 	out.WriteString("if")
-	out.WriteString(ie.Condition.String())
+	out.WriteString(condition.String())
 	out.WriteString(" ")
-	out.WriteString(ie.Consequence.String())
-	if ie.Alternative != nil {
+	out.WriteString(consequence.String())
+	if alternative != nil {
 		out.WriteString("else ")
-		out.WriteString(ie.Alternative.String())
+		out.WriteString(alternative.String())
 	}
 	return out.String()
 }
@@ -0,0 +1,83 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+// `while (cond) { ... }`
+type WhileStatement struct {
+	Token     tokens.Token // the 'while' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) Pos() tokens.Position { return ws.Token.Start }
+func (ws *WhileStatement) End() tokens.Position {
+	if ws.Body != nil {
+		return ws.Body.End()
+	}
+	return ws.Token.End
+}
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("while")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+	return out.String()
+}
+
+// `for x in iterable { ... }`, iterates over the elements of an array, the
+// characters of a string or the keys of a hash
+type ForStatement struct {
+	Token    tokens.Token // the 'for' token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Pos() tokens.Position { return fs.Token.Start }
+func (fs *ForStatement) End() tokens.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+	return fs.Token.End
+}
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("for ")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(" ")
+	out.WriteString(fs.Body.String())
+	return out.String()
+}
+
+type BreakStatement struct {
+	Token     tokens.Token // break
+	Semicolon tokens.Token // the closing ;
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) Pos() tokens.Position { return bs.Token.Start }
+func (bs *BreakStatement) End() tokens.Position { return statementEnd(bs.Token, nil, bs.Semicolon) }
+func (bs *BreakStatement) String() string       { return bs.TokenLiteral() + ";" }
+
+type ContinueStatement struct {
+	Token     tokens.Token // continue
+	Semicolon tokens.Token // the closing ;
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) Pos() tokens.Position { return cs.Token.Start }
+func (cs *ContinueStatement) End() tokens.Position { return statementEnd(cs.Token, nil, cs.Semicolon) }
+func (cs *ContinueStatement) String() string       { return cs.TokenLiteral() + ";" }
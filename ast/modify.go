@@ -0,0 +1,139 @@
+package ast
+
+import "fmt"
+
+// ModifierFunc gets every node and returns the node to put in its place,
+// returning the node itself leaves it unchanged
+type ModifierFunc func(Node) Node
+
+// Modify walks the tree post-order, the children of a node are modified before
+// the node itself is passed to modifier. children are replaced in place so the
+// parents keep their tokens and positions. a replacement must fit the field it
+// goes into, an Expression for an Expression, a *BlockStatement for a body...
+// otherwise Modify panics
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	// statements
+	case *Program:
+		modifyStatements(n.Statements, modifier)
+	case *BlockStatement:
+		modifyStatements(n.Statements, modifier)
+	case *LetStatement:
+		n.Name = modifyIdentifier(n.Name, modifier)
+		n.Value = modifyExpression(n.Value, modifier)
+	case *ReturnStatement:
+		n.ReturnValue = modifyExpression(n.ReturnValue, modifier)
+	case *AssignmentStatement:
+		n.Name = modifyIdentifier(n.Name, modifier)
+		n.Value = modifyExpression(n.Value, modifier)
+	case *ExpressionStatement:
+		n.Expression = modifyExpression(n.Expression, modifier)
+	case *IfStatement:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Consequence = modifyBlock(n.Consequence, modifier)
+		n.Alternative = modifyBlock(n.Alternative, modifier)
+	case *FunctionStatement:
+		n.Name = modifyIdentifier(n.Name, modifier)
+		modifyIdentifiers(n.Parameters, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *WhileStatement:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *ForStatement:
+		n.Variable = modifyIdentifier(n.Variable, modifier)
+		n.Iterable = modifyExpression(n.Iterable, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *BadStatement, *BreakStatement, *ContinueStatement:
+		// nothing to do
+
+	// expressions
+	case *Identifier, *IntegerLiteral, *FloatLiteral, *StringLiteral, *Boolean, *BadExpression, *Comment:
+		// nothing to do
+	case *ArrayLiteral:
+		modifyExpressions(n.Elements, modifier)
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			pair.Key = modifyExpression(pair.Key, modifier)
+			pair.Value = modifyExpression(pair.Value, modifier)
+		}
+	case *IfExpression:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Consequence = modifyBlock(n.Consequence, modifier)
+		n.Alternative = modifyBlock(n.Alternative, modifier)
+	case *PrefixExpression:
+		n.Right = modifyExpression(n.Right, modifier)
+	case *InfixExpression:
+		n.Left = modifyExpression(n.Left, modifier)
+		n.Right = modifyExpression(n.Right, modifier)
+	case *CallExpression:
+		n.Function = modifyExpression(n.Function, modifier)
+		modifyExpressions(n.Arguments, modifier)
+	case *IndexExpression:
+		n.Left = modifyExpression(n.Left, modifier)
+		n.Index = modifyExpression(n.Index, modifier)
+	case *FunctionLiteral:
+		modifyIdentifiers(n.Parameters, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	default:
+		panic(fmt.Sprintf("ast.Modify: unexpected node type %T", n))
+	}
+	return modifier(node)
+}
+
+func modifyStatements(list []Statement, modifier ModifierFunc) {
+	for i, s := range list {
+		if s == nil {
+			continue
+		}
+		modified, ok := Modify(s, modifier).(Statement)
+		if !ok {
+			panic(fmt.Sprintf("ast.Modify: %T can not replace the statement %T", modified, s))
+		}
+		list[i] = modified
+	}
+}
+
+func modifyExpression(e Expression, modifier ModifierFunc) Expression {
+	if e == nil {
+		return nil
+	}
+	modified, ok := Modify(e, modifier).(Expression)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: %T can not replace the expression %T", modified, e))
+	}
+	return modified
+}
+
+func modifyExpressions(list []Expression, modifier ModifierFunc) {
+	for i, e := range list {
+		list[i] = modifyExpression(e, modifier)
+	}
+}
+
+func modifyIdentifier(i *Identifier, modifier ModifierFunc) *Identifier {
+	if i == nil {
+		return nil
+	}
+	modified, ok := Modify(i, modifier).(*Identifier)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: %T can not replace the identifier %s", modified, i))
+	}
+	return modified
+}
+
+func modifyIdentifiers(list []*Identifier, modifier ModifierFunc) {
+	for i, ident := range list {
+		list[i] = modifyIdentifier(ident, modifier)
+	}
+}
+
+func modifyBlock(b *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if b == nil {
+		return nil
+	}
+	modified, ok := Modify(b, modifier).(*BlockStatement)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: %T can not replace a block statement", modified))
+	}
+	return modified
+}
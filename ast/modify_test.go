@@ -0,0 +1,128 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+func turnOneIntoTwo(node ast.Node) ast.Node {
+	integer, ok := node.(*ast.IntegerLiteral)
+	if !ok || integer.Value != 1 {
+		return node
+	}
+	tok := integer.Token
+	tok.Literal = "2"
+	return &ast.IntegerLiteral{Token: tok, Value: 2}
+}
+
+func TestModify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1", "2"},
+		{"let a = 1;", "let a = 2;"},
+		{"return 1;", "return 2;"},
+		{"a = 1;", "a = 2;"},
+		{"1 + 2", "(2 + 2)"},
+		{"2 + 1", "(2 + 2)"},
+		{"-1", "(-2)"},
+		{"[1, 1]", "[2, 2]"},
+		{"{1: 1}", "{2: 2}"},
+		{"f(1, 1)", "f(2, 2)"},
+		{"a[1]", "(a[2])"},
+		{"[1][1]", "([2][2])"},
+		{"if (1) { a = 1; } else { a = 1; }", "if2 a = 2;else a = 2;"},
+		{"fn f(a) { return 1; }", "fn f(a) return 2;"},
+		{"fn(a) { 1 }(1)", "fn(a) 2(2)"},
+		{`"one"`, `"one"`},
+		{"true", "true"},
+	}
+	for i, tt := range tests {
+		program := parseProgram(t, tt.input)
+		modified := ast.Modify(program, turnOneIntoTwo)
+		if modified.String() != tt.expected {
+			t.Errorf("test [%d] expected=%q, got=%q", i, tt.expected, modified.String())
+		}
+	}
+}
+
+func TestModifyIdentifiers(t *testing.T) {
+	program := parseProgram(t, "let x = 1; fn f(x) { return x; } x")
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Value == "x" {
+			tok := ident.Token
+			tok.Literal = "y"
+			return &ast.Identifier{Token: tok, Value: "y"}
+		}
+		return node
+	})
+	expected := "let y = 1;fn f(y) return y;y"
+	if program.String() != expected {
+		t.Errorf("expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestModifyIsPostOrder(t *testing.T) {
+	program := parseProgram(t, "1 + 1")
+	// by the time the infix expression is seen its operands are already replaced
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		node = turnOneIntoTwo(node)
+		if infix, ok := node.(*ast.InfixExpression); ok {
+			if infix.Left.String() != "2" || infix.Right.String() != "2" {
+				t.Errorf("children not modified before parent. got=%s", infix)
+			}
+		}
+		return node
+	})
+}
+
+func TestModifyPreservesPositions(t *testing.T) {
+	program := parseProgram(t, "let a = 1 + 1;")
+	let := program.Statements[0].(*ast.LetStatement)
+	start, end := let.Pos(), let.End()
+	ast.Modify(program, turnOneIntoTwo)
+	if let.Pos() != start || let.End() != end {
+		t.Errorf("span changed. expected=%s-%s, got=%s-%s", start, end, let.Pos(), let.End())
+	}
+	right := let.Value.(*ast.InfixExpression).Right
+	if right.Pos().String() != "1:13" {
+		t.Errorf("replacement lost its position. got=%s", right.Pos())
+	}
+}
+
+func TestModifyReplacesStatements(t *testing.T) {
+	program := parseProgram(t, "a = 1; if (a) { b = 2; }")
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		assign, ok := node.(*ast.AssignmentStatement)
+		if !ok {
+			return node
+		}
+		name := &ast.Identifier{
+			Token: tokens.Token{Type: tokens.TokenTypeIdentifier, Literal: assign.TokenLiteral()},
+			Value: assign.TokenLiteral(),
+		}
+		return &ast.LetStatement{Token: tokens.Token{Type: tokens.TokenTypeLet, Literal: "let"}, Name: name, Value: assign.Value}
+	})
+	expected := "let a = 1;ifa let b = 2;"
+	if program.String() != expected {
+		t.Errorf("expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestModifyPanicsOnWrongReplacement(t *testing.T) {
+	program := parseProgram(t, "let a = 1;")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic when an expression is replaced by a statement")
+		}
+	}()
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		if _, ok := node.(*ast.IntegerLiteral); ok {
+			return &ast.BadStatement{}
+		}
+		return node
+	})
+}
@@ -1,10 +1,16 @@
 package ast
 
-import "bytes"
+import (
+	"bytes"
+
+	"github.com/eyanshu1997/yacgo/tokens"
+)
 
 // Represents the complete program
 type Program struct {
 	Statements []Statement
+	Comments   []*Comment // every comment in source order, only set when the lexer returns comments
+	CommentMap CommentMap // the comments by the node they belong to
 }
 
 func (p *Program) TokenLiteral() string {
@@ -15,6 +21,20 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() tokens.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return tokens.Position{}
+}
+
+func (p *Program) End() tokens.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return tokens.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 	for _, s := range p.Statements {
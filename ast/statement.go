@@ -7,13 +7,18 @@ import (
 )
 
 type LetStatement struct {
-	Token tokens.Token // let
-	Name  *Identifier  // identifier
-	Value Expression   // expression
+	Token     tokens.Token // let
+	Name      *Identifier  // identifier
+	Value     Expression   // expression
+	Semicolon tokens.Token // the closing ;
 }
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() tokens.Position { return ls.Token.Start }
+func (ls *LetStatement) End() tokens.Position {
+	return statementEnd(ls.Token, ls.Value, ls.Semicolon)
+}
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(ls.TokenLiteral() + " ")
@@ -30,10 +35,15 @@ func (ls *LetStatement) String() string {
 type ReturnStatement struct {
 	Token       tokens.Token // return
 	ReturnValue Expression   // expression
+	Semicolon   tokens.Token // the closing ;
 }
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() tokens.Position { return rs.Token.Start }
+func (rs *ReturnStatement) End() tokens.Position {
+	return statementEnd(rs.Token, rs.ReturnValue, rs.Semicolon)
+}
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(rs.TokenLiteral() + " ")
@@ -46,15 +56,21 @@ func (rs *ReturnStatement) String() string {
 }
 
 type AssignmentStatement struct {
-	Token tokens.Token // return
-	Value Expression
+	Token     tokens.Token // identifier that is assigned to
+	Name      *Identifier
+	Value     Expression
+	Semicolon tokens.Token // the closing ;
 }
 
 func (as *AssignmentStatement) statementNode()       {}
 func (as *AssignmentStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssignmentStatement) Pos() tokens.Position { return as.Token.Start }
+func (as *AssignmentStatement) End() tokens.Position {
+	return statementEnd(as.Token, as.Value, as.Semicolon)
+}
 func (as *AssignmentStatement) String() string {
 	var out bytes.Buffer
-	out.WriteString(as.TokenLiteral() + " ")
+	out.WriteString(as.Name.String() + " ")
 	out.WriteString("= ")
 	if as.Value != nil {
 		out.WriteString(as.Value.String())
@@ -64,3 +80,45 @@ func (as *AssignmentStatement) String() string {
 	return out.String()
 
 }
+
+type ExpressionStatement struct {
+	Token      tokens.Token // the first token of the expression
+	Expression Expression
+	Semicolon  tokens.Token // the optional closing ;
+}
+
+func (es *ExpressionStatement) statementNode()       {}
+func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() tokens.Position { return es.Token.Start }
+func (es *ExpressionStatement) End() tokens.Position {
+	return statementEnd(es.Token, es.Expression, es.Semicolon)
+}
+func (es *ExpressionStatement) String() string {
+	if es.Expression != nil {
+		return es.Expression.String()
+	}
+	return ""
+}
+
+// a statement ends at its semicolon, or at its value when the semicolon is missing
+func statementEnd(tok tokens.Token, value Expression, semicolon tokens.Token) tokens.Position {
+	if semicolon.End.IsValid() {
+		return semicolon.End
+	}
+	if value != nil {
+		return value.End()
+	}
+	return tok.End
+}
+
+// BadStatement is a placeholder for a statement that could not be parsed
+type BadStatement struct {
+	Token tokens.Token    // first token of the broken statement
+	To    tokens.Position // end of the skipped source
+}
+
+func (bs *BadStatement) statementNode()       {}
+func (bs *BadStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BadStatement) String() string       { return "<bad statement>" }
+func (bs *BadStatement) Pos() tokens.Position { return bs.Token.Start }
+func (bs *BadStatement) End() tokens.Position { return bs.To }
@@ -0,0 +1,116 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/parser"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	l := lexer.NewLexer(input)
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+	for _, msg := range p.Errors() {
+		t.Fatalf("parser error for %q: %q", input, msg)
+	}
+	return program
+}
+
+func TestInspectVisitsEveryNodeKind(t *testing.T) {
+	input := `let a = [1, "two", true];
+fn add(x, y) { return x + y; }
+if (!a) { a = {"k": -1}; } else { add(a[0], 2); }
+let f = fn(z) { z };`
+	program := parseProgram(t, input)
+	var visited []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		if n != nil {
+			visited = append(visited, strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast."))
+		}
+		return true
+	})
+	expected := []string{
+		"Program",
+		"LetStatement", "Identifier", "ArrayLiteral", "IntegerLiteral", "StringLiteral", "Boolean",
+		"FunctionStatement", "Identifier", "Identifier", "Identifier", "BlockStatement",
+		"ReturnStatement", "InfixExpression", "Identifier", "Identifier",
+		"IfStatement", "PrefixExpression", "Identifier",
+		"BlockStatement", "AssignmentStatement", "Identifier", "HashLiteral", "StringLiteral", "PrefixExpression", "IntegerLiteral",
+		"BlockStatement", "ExpressionStatement", "CallExpression", "Identifier",
+		"IndexExpression", "Identifier", "IntegerLiteral", "IntegerLiteral",
+		"LetStatement", "Identifier", "FunctionLiteral", "Identifier", "BlockStatement",
+		"ExpressionStatement", "Identifier",
+	}
+	if strings.Join(visited, " ") != strings.Join(expected, " ") {
+		t.Errorf("wrong traversal.\nexpected=%v\ngot=     %v", expected, visited)
+	}
+}
+
+func TestInspectPrunes(t *testing.T) {
+	program := parseProgram(t, "let f = fn(a) { let b = 1; }; let c = 2;")
+	var names []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.Identifier:
+			names = append(names, n.Value)
+		}
+		return true
+	})
+	if strings.Join(names, ",") != "f,c" {
+		t.Errorf("function body was not skipped. got=%v", names)
+	}
+}
+
+type depthVisitor struct {
+	depth    int
+	maxDepth *int
+	calls    *[]string
+}
+
+func (v depthVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*v.calls = append(*v.calls, fmt.Sprintf("end@%d", v.depth))
+		return nil
+	}
+	if v.depth > *v.maxDepth {
+		*v.maxDepth = v.depth
+	}
+	*v.calls = append(*v.calls, fmt.Sprintf("%s@%d", node.TokenLiteral(), v.depth))
+	return depthVisitor{depth: v.depth + 1, maxDepth: v.maxDepth, calls: v.calls}
+}
+
+func TestWalkCallsVisitNilAfterChildren(t *testing.T) {
+	program := parseProgram(t, "x = 1 + 2;")
+	maxDepth := 0
+	calls := []string{}
+	ast.Walk(depthVisitor{maxDepth: &maxDepth, calls: &calls}, program)
+	expected := []string{
+		"x@0", "x@1", "x@2", "end@3", "+@2", "1@3", "end@4", "2@3", "end@4", "end@3", "end@2", "end@1",
+	}
+	if strings.Join(calls, " ") != strings.Join(expected, " ") {
+		t.Errorf("wrong calls.\nexpected=%v\ngot=     %v", expected, calls)
+	}
+	if maxDepth != 3 {
+		t.Errorf("wrong max depth. got=%d", maxDepth)
+	}
+}
+
+func TestWalkSkipsNilChildren(t *testing.T) {
+	node := &ast.ReturnStatement{}
+	count := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n != nil {
+			count++
+		}
+		return true
+	})
+	if count != 1 {
+		t.Errorf("expected only the return statement to be visited. got=%d", count)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eyanshu1997/yacgo/objfile"
+)
+
+// runBuild compiles a file into an object file and returns the exit code
+func runBuild(args []string) int {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	output := flags.String("o", "", "the object file to write, the source name with .ybc by default")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: yacgo build [-o prog.ybc] file\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	name := flags.Arg(0)
+	source, err := os.ReadFile(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "build: %s\n", err)
+		return 1
+	}
+	bytecode, code := compileSource(name, string(source))
+	if bytecode == nil {
+		return code
+	}
+	data, err := objfile.Encode(bytecode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "build: %s\n", err)
+		return 1
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(name, filepath.Ext(name)) + ".ybc"
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "build: %s\n", err)
+		return 1
+	}
+	return 0
+}
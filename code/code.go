@@ -0,0 +1,266 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions is a sequence of encoded instructions: an opcode byte followed
+// by its operands, big endian
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+	OpNull
+	OpTrue
+	OpFalse
+	// pushes nothing as the value of a program whose last statement has none, like a let
+	OpVoid
+
+	// operators, they pop their operands and push the result
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpShl
+	OpShr
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpLessEqual
+	OpGreaterThan
+	OpGreaterEqual
+	OpMinus
+	OpBang
+	OpBitNot
+
+	OpJump
+	OpJumpNotTruthy
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	// a local used by a closure lives in a cell, the local slot holds the cell
+	OpNewCell
+	OpGetCell
+	OpSetCell
+	OpLoadCell // pushes the cell itself, to build a closure
+	OpGetFree
+	OpSetFree
+	OpLoadFree
+	OpGetBuiltin
+
+	OpArray
+	OpHash
+	OpIndex
+
+	OpCall
+	OpReturnValue
+	OpClosure
+
+	// for x in iterable
+	OpIter
+	OpIterNext
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int // bytes of each operand
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpNull:     {"OpNull", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+	OpVoid:     {"OpVoid", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpPow:          {"OpPow", []int{}},
+	OpBitAnd:       {"OpBitAnd", []int{}},
+	OpBitOr:        {"OpBitOr", []int{}},
+	OpBitXor:       {"OpBitXor", []int{}},
+	OpShl:          {"OpShl", []int{}},
+	OpShr:          {"OpShr", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpMinus:        {"OpMinus", []int{}},
+	OpBang:         {"OpBang", []int{}},
+	OpBitNot:       {"OpBitNot", []int{}},
+
+	// jumps take the absolute offset of their target
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+
+	// the reads of variables that can be used before they are set also take
+	// the constant of the name, for the error
+	OpGetGlobal:  {"OpGetGlobal", []int{2, 2}},
+	OpSetGlobal:  {"OpSetGlobal", []int{2}},
+	OpGetLocal:   {"OpGetLocal", []int{1}},
+	OpSetLocal:   {"OpSetLocal", []int{1}},
+	OpNewCell:    {"OpNewCell", []int{1}},
+	OpGetCell:    {"OpGetCell", []int{1, 2}},
+	OpSetCell:    {"OpSetCell", []int{1}},
+	OpLoadCell:   {"OpLoadCell", []int{1}},
+	OpGetFree:    {"OpGetFree", []int{1, 2}},
+	OpSetFree:    {"OpSetFree", []int{1}},
+	OpLoadFree:   {"OpLoadFree", []int{1}},
+	OpGetBuiltin: {"OpGetBuiltin", []int{1}},
+
+	OpArray: {"OpArray", []int{2}}, // number of elements
+	OpHash:  {"OpHash", []int{2}},  // number of keys and values
+	OpIndex: {"OpIndex", []int{}},
+
+	OpCall:        {"OpCall", []int{1}}, // number of arguments
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpClosure:     {"OpClosure", []int{2, 1}}, // constant of the function, number of free variables
+
+	OpIter:     {"OpIter", []int{}},
+	OpIterNext: {"OpIterNext", []int{2}}, // where to jump once the iterator is done
+}
+
+func Lookup(op Opcode) (*Definition, error) {
+	def, ok := definitions[op]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make encodes one instruction, an unknown opcode makes an empty one
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction of def from ins, which
+// starts just after the opcode. it also returns how many bytes they took
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
+
+// String disassembles the instructions, one per line with its offset
+func (ins Instructions) String() string {
+	return ins.Format(nil)
+}
+
+// Format disassembles the instructions like String, annotate can add a comment
+// after the operands of an instruction, an empty one adds nothing
+func (ins Instructions) Format(annotate func(op Opcode, operands []int) string) string {
+	var out bytes.Buffer
+	for i := 0; i < len(ins); {
+		def, err := Lookup(Opcode(ins[i]))
+		if err != nil {
+			fmt.Fprintf(&out, "%04d ERROR: %s\n", i, err)
+			i++
+			continue
+		}
+		widths := 0
+		for _, w := range def.OperandWidths {
+			widths += w
+		}
+		if i+1+widths > len(ins) {
+			fmt.Fprintf(&out, "%04d ERROR: %s is cut short\n", i, def.Name)
+			break
+		}
+		operands, read := ReadOperands(def, ins[i+1:])
+		line := def.Name
+		for _, o := range operands {
+			line += fmt.Sprintf(" %d", o)
+		}
+		if annotate != nil {
+			if note := annotate(Opcode(ins[i]), operands); note != "" {
+				line = fmt.Sprintf("%-20s ; %s", line, note)
+			}
+		}
+		fmt.Fprintf(&out, "%04d %s\n", i, line)
+		i += 1 + read
+	}
+	return out.String()
+}
+
+// LineEntry says the instructions from Offset on come from the source at Line
+// and Column, up to the offset of the next entry
+type LineEntry struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// LineTable maps the instructions of a function back to the source, its
+// entries are in the order of their offsets
+type LineTable []LineEntry
+
+// Lookup finds the entry of the instruction at offset, false when the table
+// does not cover it
+func (lt LineTable) Lookup(offset int) (LineEntry, bool) {
+	found := -1
+	for i, entry := range lt {
+		if entry.Offset > offset {
+			break
+		}
+		found = i
+	}
+	if found < 0 {
+		return LineEntry{}, false
+	}
+	return lt[found], true
+}
@@ -0,0 +1,110 @@
+package code
+
+import "testing"
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpGetLocal, []int{255}, []byte{byte(OpGetLocal), 255}},
+		{OpClosure, []int{65534, 255}, []byte{byte(OpClosure), 255, 254, 255}},
+		{Opcode(255), []int{}, []byte{}},
+	}
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+		if string(instruction) != string(tt.expected) {
+			t.Errorf("wrong encoding for %d %v. expected=%v, got=%v", tt.op, tt.operands, tt.expected, instruction)
+		}
+	}
+}
+
+func TestReadOperands(t *testing.T) {
+	tests := []struct {
+		op        Opcode
+		operands  []int
+		bytesRead int
+	}{
+		{OpConstant, []int{65535}, 2},
+		{OpSetCell, []int{7}, 1},
+		{OpClosure, []int{300, 4}, 3},
+		{OpPop, []int{}, 0},
+	}
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+		def, err := Lookup(tt.op)
+		if err != nil {
+			t.Fatalf("definition not found: %q", err)
+		}
+		operands, n := ReadOperands(def, instruction[1:])
+		if n != tt.bytesRead {
+			t.Errorf("wrong number of bytes read for %s. expected=%d, got=%d", def.Name, tt.bytesRead, n)
+		}
+		for i, want := range tt.operands {
+			if operands[i] != want {
+				t.Errorf("wrong operand %d for %s. expected=%d, got=%d", i, def.Name, want, operands[i])
+			}
+		}
+	}
+}
+
+func TestEveryOpcodeIsDefined(t *testing.T) {
+	for op := OpConstant; op <= OpIterNext; op++ {
+		if _, err := Lookup(op); err != nil {
+			t.Errorf("opcode %d has no definition", op)
+		}
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	instructions := []Instructions{
+		Make(OpAdd),
+		Make(OpGetLocal, 1),
+		Make(OpConstant, 2),
+		Make(OpConstant, 65535),
+		Make(OpClosure, 65535, 255),
+		{255},
+		Make(OpJump, 1)[:2],
+	}
+	expected := `0000 OpAdd
+0001 OpGetLocal 1
+0003 OpConstant 2
+0006 OpConstant 65535
+0009 OpClosure 65535 255
+0013 ERROR: opcode 255 undefined
+0014 ERROR: OpJump is cut short
+`
+	var concatted Instructions
+	for _, ins := range instructions {
+		concatted = append(concatted, ins...)
+	}
+	if concatted.String() != expected {
+		t.Errorf("instructions wrongly formatted.\nexpected=%q\ngot=     %q", expected, concatted.String())
+	}
+}
+
+func TestLineTableLookup(t *testing.T) {
+	lines := LineTable{{Offset: 0, Line: 1, Column: 1}, {Offset: 5, Line: 2, Column: 3}, {Offset: 9, Line: 4, Column: 1}}
+	tests := []struct {
+		offset int
+		line   int
+		found  bool
+	}{
+		{0, 1, true},
+		{4, 1, true},
+		{5, 2, true},
+		{100, 4, true},
+	}
+	for _, tt := range tests {
+		entry, ok := lines.Lookup(tt.offset)
+		if ok != tt.found || entry.Line != tt.line {
+			t.Errorf("wrong entry for offset %d. expected line %d, got=%+v", tt.offset, tt.line, entry)
+		}
+	}
+	if _, ok := (LineTable{{Offset: 2, Line: 1}}).Lookup(1); ok {
+		t.Errorf("an offset before the first entry should not be found")
+	}
+}
@@ -9,16 +9,19 @@ import (
 
 var enabled bool
 
+// Println logs str with the file and line of its caller. the caller lookup is
+// only done when logging is enabled, the lexer logs every character
 func Println(str string) {
-	_, file, no, _ := runtime.Caller(1)
 	if enabled {
+		_, file, no, _ := runtime.Caller(1)
 		log.Println(fmt.Sprintf(" %s %s"+str, file, no))
 	}
 }
 
+// Printf is Println with formatting, the caller is looked up the same way
 func Printf(str string, args ...interface{}) {
-	_, file, no, _ := runtime.Caller(1)
 	if enabled {
+		_, file, no, _ := runtime.Caller(1)
 		log.Printf(fmt.Sprintf(" %s %s"+str, file, no), args...)
 	}
 }
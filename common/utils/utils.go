@@ -1,9 +1,53 @@
 package utils
 
-func IsLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// any unicode letter or _, identifiers start with one
+func IsLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+// letters and any unicode digit can follow the first letter of an identifier
+func IsIdentifierChar(ch rune) bool {
+	return IsLetter(ch) || unicode.IsDigit(ch)
 }
 
-func IsDigit(ch byte) bool {
+// only ascii digits make numbers
+func IsDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
+
+func IsHexDigit(ch rune) bool {
+	return IsDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// QuoteString is the inverse of the lexer's string decoding, it returns s in
+// double quotes with the characters that need it escaped
+func QuoteString(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			if r < ' ' || r == 0x7f {
+				fmt.Fprintf(&out, `\u{%x}`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
@@ -0,0 +1,564 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/code"
+	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/object"
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+// operand of a jump before its target is known
+const placeholder = 0xffff
+
+var infixOperators = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"**": code.OpPow,
+	"&":  code.OpBitAnd,
+	"|":  code.OpBitOr,
+	"^":  code.OpBitXor,
+	"<<": code.OpShl,
+	">>": code.OpShr,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	"<=": code.OpLessEqual,
+	">":  code.OpGreaterThan,
+	">=": code.OpGreaterEqual,
+}
+
+var prefixOperators = map[string]code.Opcode{
+	"-": code.OpMinus,
+	"!": code.OpBang,
+	"~": code.OpBitNot,
+}
+
+// Bytecode is a compiled program, the vm runs it
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	NumLocals    int // locals of the blocks at the top level of the program
+	Lines        code.LineTable
+}
+
+// Compiler lowers a resolved program to bytecode. the globals are the slots the
+// resolver gave them, so a compiler can be used for one program after another
+// together with one resolver, like the repl does
+type Compiler struct {
+	constants []object.Object
+	names     map[string]int  // constants of the names of variables
+	units     []*unit         // the program first, then the functions being compiled
+	scopes    []*scope        // innermost last
+	position  tokens.Position // of the node being compiled, for the line tables
+}
+
+func New() *Compiler {
+	return &Compiler{names: make(map[string]int)}
+}
+
+// NewWithState continues with the constants of an earlier compiler
+func NewWithState(constants []object.Object) *Compiler {
+	c := &Compiler{constants: constants, names: make(map[string]int)}
+	for i, constant := range constants {
+		if s, ok := constant.(*object.String); ok {
+			c.names[s.Value] = i
+		}
+	}
+	return c
+}
+
+// Compile compiles a program the resolver has gone over without errors. the
+// value of the program, left on the stack at the end, is the one of its last
+// statement
+func (c *Compiler) Compile(program *ast.Program) error {
+	c.units = []*unit{{}}
+	c.scopes = []*scope{{captured: map[int]bool{}}}
+	if err := c.statementsValue(program.Statements, code.OpVoid); err != nil {
+		return err
+	}
+	if c.here() > maxInstructions {
+		return fmt.Errorf("%s: program too large, its jumps cannot reach past %d bytes", program.Pos(), maxInstructions)
+	}
+	return nil
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	main := c.units[0]
+	return &Bytecode{Instructions: main.instructions, Constants: c.constants, NumLocals: main.numLocals, Lines: main.lines}
+}
+
+// the instructions emitted until the returned function is called come from node
+func (c *Compiler) at(node ast.Node) func() {
+	previous := c.position
+	c.position = node.Pos()
+	return func() { c.position = previous }
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	u := c.currentUnit()
+	position := len(u.instructions)
+	if c.position.IsValid() {
+		last := len(u.lines) - 1
+		if last < 0 || u.lines[last].Line != c.position.Line || u.lines[last].Column != c.position.Column {
+			u.lines = append(u.lines, code.LineEntry{Offset: position, Line: c.position.Line, Column: c.position.Column})
+		}
+	}
+	u.instructions = append(u.instructions, code.Make(op, operands...)...)
+	u.depth += stackEffect(op, operands)
+	return position
+}
+
+// sets the operand of the jump at position
+func (c *Compiler) changeOperand(position int, operand int) {
+	u := c.currentUnit()
+	op := code.Opcode(u.instructions[position])
+	copy(u.instructions[position:], code.Make(op, operand))
+}
+
+// the offset of the next instruction, where the jumps to here go
+func (c *Compiler) here() int {
+	return len(c.currentUnit().instructions)
+}
+
+func (c *Compiler) addConstant(obj object.Object) (int, error) {
+	if len(c.constants) == maxConstants {
+		return 0, fmt.Errorf("too many constants")
+	}
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1, nil
+}
+
+// the constant holding the name of ident, shared by all the uses of the name
+func (c *Compiler) name(ident *ast.Identifier) (int, error) {
+	if index, ok := c.names[ident.Value]; ok {
+		return index, nil
+	}
+	index, err := c.addConstant(&object.String{Value: ident.Value})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", ident.Pos(), err)
+	}
+	c.names[ident.Value] = index
+	return index, nil
+}
+
+func (c *Compiler) constant(node ast.Node, obj object.Object) error {
+	index, err := c.addConstant(obj)
+	if err != nil {
+		return fmt.Errorf("%s: %s", node.Pos(), err)
+	}
+	c.emit(code.OpConstant, index)
+	return nil
+}
+
+func (c *Compiler) statements(list []ast.Statement) error {
+	for _, s := range list {
+		if err := c.statement(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compiles list leaving the value of its last statement on the stack, like a
+// function body. void is pushed when that statement has no value
+func (c *Compiler) statementsValue(list []ast.Statement, void code.Opcode) error {
+	if len(list) == 0 {
+		c.emit(void)
+		return nil
+	}
+	if err := c.statements(list[:len(list)-1]); err != nil {
+		return err
+	}
+	switch last := list[len(list)-1].(type) {
+	case *ast.ExpressionStatement:
+		return c.expression(last.Expression)
+	case *ast.IfStatement:
+		return c.conditionalValue(last.Condition, last.Consequence, last.Alternative, void)
+	default:
+		if err := c.statement(last); err != nil {
+			return err
+		}
+		c.emit(void)
+		return nil
+	}
+}
+
+func (c *Compiler) statement(s ast.Statement) error {
+	log.Printf("compiling statement [%T] %s", s, s)
+	defer c.at(s)()
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		if err := c.expression(s.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	case *ast.LetStatement:
+		if err := c.namedValue(s.Name.Value, s.Value); err != nil {
+			return err
+		}
+		return c.store(s.Name)
+	case *ast.AssignmentStatement:
+		if err := c.namedValue(s.Name.Value, s.Value); err != nil {
+			return err
+		}
+		return c.store(s.Name)
+	case *ast.ReturnStatement:
+		if err := c.expression(s.ReturnValue); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+	case *ast.FunctionStatement:
+		source := object.FunctionSource(s.Name.Value, s.Parameters, s.Body)
+		if err := c.function(s.Name.Value, source, s.Parameters, s.Body); err != nil {
+			return err
+		}
+		return c.store(s.Name)
+	case *ast.IfStatement:
+		return c.conditional(s.Condition, s.Consequence, s.Alternative)
+	case *ast.WhileStatement:
+		return c.whileLoop(s)
+	case *ast.ForStatement:
+		return c.forLoop(s)
+	case *ast.BreakStatement:
+		l, err := c.innermostLoop(s)
+		if err != nil {
+			return err
+		}
+		// a break in an if expression leaves the values of the expression behind
+		c.popTo(l.depth)
+		l.breaks = append(l.breaks, c.emit(code.OpJump, placeholder))
+	case *ast.ContinueStatement:
+		l, err := c.innermostLoop(s)
+		if err != nil {
+			return err
+		}
+		c.popTo(l.depth)
+		c.emit(code.OpJump, l.continueTarget)
+	case *ast.BlockStatement:
+		return c.block(s)
+	case *ast.BadStatement:
+		return fmt.Errorf("%s: cannot compile a statement with syntax errors", s.Pos())
+	default:
+		return fmt.Errorf("%s: cannot compile %T", s.Pos(), s)
+	}
+	return nil
+}
+
+// a function literal gets the name it is bound to, for the disassembly and its Inspect
+func (c *Compiler) namedValue(name string, value ast.Expression) error {
+	if fn, ok := value.(*ast.FunctionLiteral); ok {
+		return c.function(name, object.FunctionSource("", fn.Parameters, fn.Body), fn.Parameters, fn.Body)
+	}
+	return c.expression(value)
+}
+
+func (c *Compiler) block(b *ast.BlockStatement) error {
+	if err := c.enterScope(declarations(b.Statements), 0); err != nil {
+		return err
+	}
+	defer c.leaveScope()
+	return c.statements(b.Statements)
+}
+
+func (c *Compiler) blockValue(b *ast.BlockStatement, void code.Opcode) error {
+	if err := c.enterScope(declarations(b.Statements), 0); err != nil {
+		return err
+	}
+	defer c.leaveScope()
+	return c.statementsValue(b.Statements, void)
+}
+
+func (c *Compiler) conditional(condition ast.Expression, consequence, alternative *ast.BlockStatement) error {
+	if err := c.expression(condition); err != nil {
+		return err
+	}
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, placeholder)
+	if err := c.block(consequence); err != nil {
+		return err
+	}
+	if alternative == nil {
+		c.changeOperand(jumpNotTruthy, c.here())
+		return nil
+	}
+	jump := c.emit(code.OpJump, placeholder)
+	c.changeOperand(jumpNotTruthy, c.here())
+	if err := c.block(alternative); err != nil {
+		return err
+	}
+	c.changeOperand(jump, c.here())
+	return nil
+}
+
+// an if that has a value, null when no branch is taken
+func (c *Compiler) conditionalValue(condition ast.Expression, consequence, alternative *ast.BlockStatement, void code.Opcode) error {
+	if err := c.expression(condition); err != nil {
+		return err
+	}
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, placeholder)
+	depth := c.currentUnit().depth
+	if err := c.blockValue(consequence, void); err != nil {
+		return err
+	}
+	jump := c.emit(code.OpJump, placeholder)
+	c.changeOperand(jumpNotTruthy, c.here())
+	// only one branch runs, each pushes its value on the same stack
+	c.currentUnit().depth = depth
+	if alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.blockValue(alternative, void); err != nil {
+		return err
+	}
+	c.changeOperand(jump, c.here())
+	return nil
+}
+
+func (c *Compiler) whileLoop(s *ast.WhileStatement) error {
+	l := &loop{continueTarget: c.here(), depth: c.currentUnit().depth}
+	if err := c.expression(s.Condition); err != nil {
+		return err
+	}
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, placeholder)
+	u := c.currentUnit()
+	u.loops = append(u.loops, l)
+	if err := c.block(s.Body); err != nil {
+		return err
+	}
+	u.loops = u.loops[:len(u.loops)-1]
+	c.emit(code.OpJump, l.continueTarget)
+	c.changeOperand(jumpNotTruthy, c.here())
+	c.patchBreaks(l)
+	return nil
+}
+
+// the iterator stays on the stack while the loop runs, the end of the loop pops it
+func (c *Compiler) forLoop(s *ast.ForStatement) error {
+	if err := c.expression(s.Iterable); err != nil {
+		return err
+	}
+	c.emit(code.OpIter)
+	l := &loop{depth: c.currentUnit().depth}
+	l.continueTarget = c.emit(code.OpIterNext, placeholder)
+	decls := append([]*ast.Identifier{s.Variable}, declarations(s.Body.Statements)...)
+	if err := c.enterScope(decls, 0); err != nil {
+		return err
+	}
+	if err := c.store(s.Variable); err != nil {
+		return err
+	}
+	u := c.currentUnit()
+	u.loops = append(u.loops, l)
+	if err := c.statements(s.Body.Statements); err != nil {
+		return err
+	}
+	u.loops = u.loops[:len(u.loops)-1]
+	c.leaveScope()
+	c.emit(code.OpJump, l.continueTarget)
+	c.changeOperand(l.continueTarget, c.here())
+	c.patchBreaks(l)
+	c.emit(code.OpPop)
+	return nil
+}
+
+func (c *Compiler) patchBreaks(l *loop) {
+	for _, position := range l.breaks {
+		c.changeOperand(position, c.here())
+	}
+}
+
+func (c *Compiler) innermostLoop(s ast.Statement) (*loop, error) {
+	loops := c.currentUnit().loops
+	if len(loops) == 0 {
+		return nil, fmt.Errorf("%s: %s is not in a loop", s.Pos(), s.TokenLiteral())
+	}
+	return loops[len(loops)-1], nil
+}
+
+func (c *Compiler) expression(e ast.Expression) error {
+	defer c.at(e)()
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		return c.constant(e, &object.Integer{Value: e.Value})
+	case *ast.FloatLiteral:
+		return c.constant(e, &object.Float{Value: e.Value})
+	case *ast.StringLiteral:
+		return c.constant(e, &object.String{Value: e.Value})
+	case *ast.Boolean:
+		if e.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.Identifier:
+		return c.load(e)
+	case *ast.PrefixExpression:
+		op, ok := prefixOperators[e.Operator]
+		if !ok {
+			return fmt.Errorf("%s: unknown operator %s", e.Pos(), e.Operator)
+		}
+		if err := c.expression(e.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+	case *ast.InfixExpression:
+		if e.Operator == "&&" || e.Operator == "||" {
+			return c.logical(e)
+		}
+		op, ok := infixOperators[e.Operator]
+		if !ok {
+			return fmt.Errorf("%s: unknown operator %s", e.Pos(), e.Operator)
+		}
+		if err := c.expression(e.Left); err != nil {
+			return err
+		}
+		if err := c.expression(e.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+	case *ast.ArrayLiteral:
+		if err := c.expressions(e.Elements); err != nil {
+			return err
+		}
+		c.emit(code.OpArray, len(e.Elements))
+	case *ast.HashLiteral:
+		for _, pair := range e.Pairs {
+			if err := c.expression(pair.Key); err != nil {
+				return err
+			}
+			if err := c.expression(pair.Value); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(e.Pairs)*2)
+	case *ast.IndexExpression:
+		if err := c.expression(e.Left); err != nil {
+			return err
+		}
+		if err := c.expression(e.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+	case *ast.CallExpression:
+		if len(e.Arguments) >= maxLocals {
+			return fmt.Errorf("%s: too many arguments", e.Pos())
+		}
+		if err := c.expression(e.Function); err != nil {
+			return err
+		}
+		if err := c.expressions(e.Arguments); err != nil {
+			return err
+		}
+		c.emit(code.OpCall, len(e.Arguments))
+	case *ast.IfExpression:
+		return c.conditionalValue(e.Condition, e.Consequence, e.Alternative, code.OpNull)
+	case *ast.FunctionLiteral:
+		return c.function("", object.FunctionSource("", e.Parameters, e.Body), e.Parameters, e.Body)
+	case *ast.BadExpression:
+		return fmt.Errorf("%s: cannot compile an expression with syntax errors", e.Pos())
+	default:
+		return fmt.Errorf("%s: cannot compile %T", e.Pos(), e)
+	}
+	return nil
+}
+
+func (c *Compiler) expressions(list []ast.Expression) error {
+	if len(list) >= maxConstants {
+		return fmt.Errorf("%s: too many elements", list[0].Pos())
+	}
+	for _, e := range list {
+		if err := c.expression(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// && and || only run their right side when the left one does not decide, the
+// result is always a boolean: !! turns the right side into one
+func (c *Compiler) logical(e *ast.InfixExpression) error {
+	if err := c.expression(e.Left); err != nil {
+		return err
+	}
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, placeholder)
+	depth := c.currentUnit().depth
+	if e.Operator == "||" {
+		c.emit(code.OpTrue)
+		jump := c.emit(code.OpJump, placeholder)
+		c.changeOperand(jumpNotTruthy, c.here())
+		c.currentUnit().depth = depth
+		if err := c.truthiness(e.Right); err != nil {
+			return err
+		}
+		c.changeOperand(jump, c.here())
+		return nil
+	}
+	if err := c.truthiness(e.Right); err != nil {
+		return err
+	}
+	jump := c.emit(code.OpJump, placeholder)
+	c.changeOperand(jumpNotTruthy, c.here())
+	c.currentUnit().depth = depth
+	c.emit(code.OpFalse)
+	c.changeOperand(jump, c.here())
+	return nil
+}
+
+func (c *Compiler) truthiness(e ast.Expression) error {
+	if err := c.expression(e); err != nil {
+		return err
+	}
+	c.emit(code.OpBang)
+	c.emit(code.OpBang)
+	return nil
+}
+
+// compiles the function into a constant and emits the closure that makes it a
+// value, with the cells of the variables it uses from the code around it
+func (c *Compiler) function(name, source string, parameters []*ast.Identifier, body *ast.BlockStatement) error {
+	c.units = append(c.units, &unit{})
+	decls := append(append([]*ast.Identifier{}, parameters...), declarations(body.Statements)...)
+	if err := c.enterScope(decls, len(parameters)); err != nil {
+		return err
+	}
+	if err := c.statementsValue(body.Statements, code.OpNull); err != nil {
+		return err
+	}
+	c.emit(code.OpReturnValue)
+	if c.here() > maxInstructions {
+		return fmt.Errorf("%s: function too large, its jumps cannot reach past %d bytes", body.Pos(), maxInstructions)
+	}
+	c.leaveScope()
+	fn := c.currentUnit()
+	c.units = c.units[:len(c.units)-1]
+
+	compiled := &object.CompiledFunction{
+		Instructions:  fn.instructions,
+		NumLocals:     fn.numLocals,
+		NumParameters: len(parameters),
+		Name:          name,
+		Source:        source,
+		Lines:         fn.lines,
+	}
+	for _, free := range fn.free {
+		if free.scope.unit == c.currentUnit() {
+			c.emit(code.OpLoadCell, free.scope.base+free.slot)
+			continue
+		}
+		index, err := c.freeIndex(len(c.units)-1, free)
+		if err != nil {
+			return fmt.Errorf("%s: %s", body.Pos(), err)
+		}
+		c.emit(code.OpLoadFree, index)
+	}
+	index, err := c.addConstant(compiled)
+	if err != nil {
+		return fmt.Errorf("%s: %s", body.Pos(), err)
+	}
+	c.emit(code.OpClosure, index, len(fn.free))
+	return nil
+}
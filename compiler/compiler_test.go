@@ -0,0 +1,202 @@
+package compiler_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/code"
+	"github.com/eyanshu1997/yacgo/compiler"
+	"github.com/eyanshu1997/yacgo/conformance"
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/object"
+	"github.com/eyanshu1997/yacgo/parser"
+)
+
+func concat(instructions ...[]byte) code.Instructions {
+	return code.Instructions(bytes.Join(instructions, nil))
+}
+
+func compile(t *testing.T, input string) *compiler.Bytecode {
+	bytecode, err := conformance.Compile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytecode
+}
+
+func TestInstructions(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  code.Instructions
+		numLocals int
+	}{
+		{
+			"1 + 2",
+			concat(code.Make(code.OpConstant, 0), code.Make(code.OpConstant, 1), code.Make(code.OpAdd)),
+			0,
+		},
+		{
+			"1; -2 ** 3",
+			concat(
+				code.Make(code.OpConstant, 0), code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1), code.Make(code.OpConstant, 2), code.Make(code.OpPow), code.Make(code.OpMinus),
+			),
+			0,
+		},
+		{
+			"let a = 1; a = a;",
+			concat(
+				code.Make(code.OpConstant, 0), code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0, 1), code.Make(code.OpSetGlobal, 0), code.Make(code.OpVoid),
+			),
+			0,
+		},
+		{
+			"if (true) { let b = 2; b }",
+			concat(
+				code.Make(code.OpTrue), code.Make(code.OpJumpNotTruthy, 14),
+				code.Make(code.OpConstant, 0), code.Make(code.OpSetLocal, 0), code.Make(code.OpGetLocal, 0),
+				code.Make(code.OpJump, 15),
+				code.Make(code.OpNull),
+			),
+			1,
+		},
+		{
+			"true && false",
+			concat(
+				code.Make(code.OpTrue), code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpFalse), code.Make(code.OpBang), code.Make(code.OpBang), code.Make(code.OpJump, 11),
+				code.Make(code.OpFalse),
+			),
+			0,
+		},
+		{
+			"for x in [] { break; }",
+			concat(
+				code.Make(code.OpArray, 0), code.Make(code.OpIter), code.Make(code.OpIterNext, 15),
+				code.Make(code.OpSetLocal, 0), code.Make(code.OpJump, 15), code.Make(code.OpJump, 4),
+				code.Make(code.OpPop), code.Make(code.OpVoid),
+			),
+			1,
+		},
+		{
+			"while (true) { continue; }",
+			concat(
+				code.Make(code.OpTrue), code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpJump, 0), code.Make(code.OpJump, 0), code.Make(code.OpVoid),
+			),
+			0,
+		},
+	}
+	for _, tt := range tests {
+		bytecode := compile(t, tt.input)
+		if !bytes.Equal(bytecode.Instructions, tt.expected) {
+			t.Errorf("wrong instructions for %q.\nexpected=%v\ngot=     %v", tt.input, tt.expected, bytecode.Instructions)
+		}
+		if bytecode.NumLocals != tt.numLocals {
+			t.Errorf("wrong number of locals for %q. expected=%d, got=%d", tt.input, tt.numLocals, bytecode.NumLocals)
+		}
+	}
+}
+
+// a continue in an expression pops what the expression pushed, down to the iterator
+func TestJumpOutOfExpression(t *testing.T) {
+	bytecode := compile(t, "for k in [1] { 1 + if (true) { continue; } else { 2 }; }")
+	expected := concat(
+		code.Make(code.OpConstant, 0), code.Make(code.OpArray, 1), code.Make(code.OpIter), code.Make(code.OpIterNext, 35),
+		code.Make(code.OpSetLocal, 0),
+		code.Make(code.OpConstant, 1), code.Make(code.OpTrue), code.Make(code.OpJumpNotTruthy, 27),
+		code.Make(code.OpPop), code.Make(code.OpJump, 7), code.Make(code.OpNull), code.Make(code.OpJump, 30),
+		code.Make(code.OpConstant, 2),
+		code.Make(code.OpAdd), code.Make(code.OpPop), code.Make(code.OpJump, 7),
+		code.Make(code.OpPop), code.Make(code.OpVoid),
+	)
+	if !bytes.Equal(bytecode.Instructions, expected) {
+		t.Errorf("wrong instructions.\nexpected=%s\ngot=     %s", expected, bytecode.Instructions)
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	bytecode := compile(t, "fn add(a, b) { let c = a + b; c }")
+	fn, ok := bytecode.Constants[0].(*object.CompiledFunction)
+	if !ok {
+		t.Fatalf("constant 0 is not a function. got=%T", bytecode.Constants[0])
+	}
+	expected := concat(
+		code.Make(code.OpGetLocal, 0), code.Make(code.OpGetLocal, 1), code.Make(code.OpAdd), code.Make(code.OpSetLocal, 2),
+		code.Make(code.OpGetLocal, 2), code.Make(code.OpReturnValue),
+	)
+	if !bytes.Equal(fn.Instructions, expected) {
+		t.Errorf("wrong function instructions.\nexpected=%v\ngot=     %v", expected, fn.Instructions)
+	}
+	if fn.NumLocals != 3 || fn.NumParameters != 2 || fn.Name != "add" || fn.Source != "fn add(a, b) {\nlet c = (a + b);c\n}" {
+		t.Errorf("wrong function %+v", fn)
+	}
+	expected = concat(code.Make(code.OpClosure, 0, 0), code.Make(code.OpSetGlobal, 0), code.Make(code.OpVoid))
+	if !bytes.Equal(bytecode.Instructions, expected) {
+		t.Errorf("wrong program instructions.\nexpected=%v\ngot=     %v", expected, bytecode.Instructions)
+	}
+}
+
+// a local a closure uses lives in a cell made when its scope is entered
+func TestCapturedLocals(t *testing.T) {
+	bytecode := compile(t, "fn(x) { let n = 0; fn() { n = n + x; n } }")
+	// constants 1 and 2 are the names n and x
+	inner := bytecode.Constants[3].(*object.CompiledFunction)
+	expected := concat(
+		code.Make(code.OpGetFree, 0, 1), code.Make(code.OpGetFree, 1, 2), code.Make(code.OpAdd), code.Make(code.OpSetFree, 0),
+		code.Make(code.OpGetFree, 0, 1), code.Make(code.OpReturnValue),
+	)
+	if !bytes.Equal(inner.Instructions, expected) {
+		t.Errorf("wrong inner instructions.\nexpected=%v\ngot=     %v", expected, inner.Instructions)
+	}
+	outer := bytecode.Constants[4].(*object.CompiledFunction)
+	expected = concat(
+		code.Make(code.OpGetLocal, 0), code.Make(code.OpNewCell, 0), code.Make(code.OpSetCell, 0),
+		code.Make(code.OpNewCell, 1),
+		code.Make(code.OpConstant, 0), code.Make(code.OpSetCell, 1),
+		code.Make(code.OpLoadCell, 1), code.Make(code.OpLoadCell, 0), code.Make(code.OpClosure, 3, 2),
+		code.Make(code.OpReturnValue),
+	)
+	if !bytes.Equal(outer.Instructions, expected) {
+		t.Errorf("wrong outer instructions.\nexpected=%v\ngot=     %v", expected, outer.Instructions)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	p := parser.NewParser(lexer.NewLexer("let a = b;"))
+	program := p.ParseProgram()
+	if err := compiler.New().Compile(program); err == nil || err.Error() != "1:9: undefined identifier: b" {
+		t.Errorf("an unresolved program should not compile. got=%v", err)
+	}
+	// the resolver rejects this, the compiler should too when given it anyway
+	program = parser.NewParser(lexer.NewLexer("len = 1;")).ParseProgram()
+	program.Statements[0].(*ast.AssignmentStatement).Name.Binding = &ast.Binding{Depth: -1}
+	if err := compiler.New().Compile(program); err == nil || err.Error() != "1:1: cannot assign to the builtin len" {
+		t.Errorf("assigning to a builtin should not compile. got=%v", err)
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	body := strings.Repeat("x; ", 12000)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; let i = 0; while (i < 2) { i = i + 1; " + body + "} i",
+			"1:1: program too large, its jumps cannot reach past 65535 bytes"},
+		{"let x = 1; let f = fn() { if (x) { " + body + "} };",
+			"1:25: function too large, its jumps cannot reach past 65535 bytes"},
+	}
+	for i, tt := range tests {
+		program, err := conformance.Parse(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := compiler.New().Compile(program); err == nil || err.Error() != tt.expected {
+			t.Errorf("test [%d] wrong error. expected=%q, got=%v", i, tt.expected, err)
+		}
+	}
+}
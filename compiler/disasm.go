@@ -0,0 +1,65 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/eyanshu1997/yacgo/code"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+// String disassembles the program and then the body of every function in the
+// constant pool, constants, variables and builtins are named in a comment where
+// they are used
+func (b *Bytecode) String() string {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "== main (%d locals) ==\n", b.NumLocals)
+	out.WriteString(b.Instructions.Format(b.annotate))
+	for i, constant := range b.Constants {
+		fn, ok := constant.(*object.CompiledFunction)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&out, "\n== %s, constant %d (%d locals) ==\n", functionName(fn), i, fn.NumLocals)
+		out.WriteString(fn.Instructions.Format(b.annotate))
+	}
+	return out.String()
+}
+
+func (b *Bytecode) annotate(op code.Opcode, operands []int) string {
+	switch op {
+	case code.OpConstant, code.OpClosure:
+		if operands[0] >= len(b.Constants) {
+			return "no such constant"
+		}
+		switch constant := b.Constants[operands[0]].(type) {
+		case *object.CompiledFunction:
+			return functionName(constant)
+		case *object.String:
+			return strconv.Quote(constant.Value)
+		default:
+			return constant.Inspect()
+		}
+	case code.OpGetGlobal, code.OpGetCell, code.OpGetFree:
+		if operands[1] < len(b.Constants) {
+			if name, ok := b.Constants[operands[1]].(*object.String); ok {
+				return name.Value
+			}
+		}
+		return "no such name"
+	case code.OpGetBuiltin:
+		if operands[0] >= len(object.Builtins) {
+			return "no such builtin"
+		}
+		return object.Builtins[operands[0]].Name
+	}
+	return ""
+}
+
+func functionName(fn *object.CompiledFunction) string {
+	if fn.Name == "" {
+		return fmt.Sprintf("fn/%d", fn.NumParameters)
+	}
+	return fmt.Sprintf("fn %s/%d", fn.Name, fn.NumParameters)
+}
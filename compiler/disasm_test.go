@@ -0,0 +1,29 @@
+package compiler_test
+
+import "testing"
+
+func TestDisassemble(t *testing.T) {
+	bytecode := compile(t, `let s = "a\n"; fn twice(x) { x * 2 } len(s) + twice(1.5)`)
+	expected := `== main (0 locals) ==
+0000 OpConstant 0         ; "a\n"
+0003 OpSetGlobal 0
+0006 OpClosure 2 0        ; fn twice/1
+0010 OpSetGlobal 1
+0013 OpGetBuiltin 0       ; len
+0015 OpGetGlobal 0 3      ; s
+0020 OpCall 1
+0022 OpGetGlobal 1 4      ; twice
+0027 OpConstant 5         ; 1.5
+0030 OpCall 1
+0032 OpAdd
+
+== fn twice/1, constant 2 (1 locals) ==
+0000 OpGetLocal 0
+0002 OpConstant 1         ; 2
+0005 OpMul
+0006 OpReturnValue
+`
+	if bytecode.String() != expected {
+		t.Errorf("wrong disassembly.\nexpected=\n%s\ngot=\n%s", expected, bytecode.String())
+	}
+}
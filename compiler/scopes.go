@@ -0,0 +1,222 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/code"
+)
+
+// most locals and free variables a function can have, their operands are one byte
+const maxLocals = 256
+
+// most globals and constants, their operands are two bytes
+const (
+	maxGlobals   = 1 << 16
+	maxConstants = 1 << 16
+)
+
+// longest code of a function or program, the jumps in it have two byte operands
+const maxInstructions = 0xffff
+
+// the code of a function being compiled, or of the program itself
+type unit struct {
+	instructions code.Instructions
+	lines        code.LineTable
+	numLocals    int
+	depth        int            // values on the operand stack where the next instruction goes
+	free         []freeVariable // variables of enclosing functions the function uses
+	loops        []*loop
+}
+
+// a variable of an enclosing function, found at slot of scope
+type freeVariable struct {
+	scope *scope
+	slot  int
+}
+
+type loop struct {
+	continueTarget int
+	breaks         []int // jumps to patch with the end of the loop
+	depth          int   // values on the operand stack at the continue target and at the end
+}
+
+// mirrors a scope of the resolver, the identifiers' bindings say which one
+// they are declared in. the variables of a function and of all the blocks in
+// it are locals of the function's frame, each scope has its own range of them
+type scope struct {
+	unit     *unit // nil for the global scope
+	base     int   // local of slot 0
+	size     int
+	captured map[int]bool // slots kept in cells
+}
+
+// where a name lives at runtime
+type location int
+
+const (
+	locationGlobal location = iota
+	locationLocal
+	locationCell
+	locationFree
+	locationBuiltin
+)
+
+func (c *Compiler) currentUnit() *unit {
+	return c.units[len(c.units)-1]
+}
+
+// enters a function or block scope declaring decls, parameters are the first
+// decls of a function scope and already hold the arguments
+func (c *Compiler) enterScope(decls []*ast.Identifier, parameters int) error {
+	u := c.currentUnit()
+	s := &scope{unit: u, captured: make(map[int]bool)}
+	if parent := c.scopes[len(c.scopes)-1]; parent.unit == u {
+		s.base = parent.base + parent.size
+	}
+	for _, decl := range decls {
+		if decl.Binding == nil {
+			return fmt.Errorf("%s: unresolved declaration of %s", decl.Pos(), decl.Value)
+		}
+		if decl.Binding.Slot >= s.size {
+			s.size = decl.Binding.Slot + 1
+		}
+		if decl.Binding.Captured {
+			s.captured[decl.Binding.Slot] = true
+		}
+	}
+	if len(decls) > 0 && s.base+s.size > maxLocals {
+		return fmt.Errorf("%s: too many local variables", decls[len(decls)-1].Pos())
+	}
+	if s.base+s.size > u.numLocals {
+		u.numLocals = s.base + s.size
+	}
+	c.scopes = append(c.scopes, s)
+	// every time the scope is entered its captured variables get new cells, so
+	// closures made in different iterations of a loop do not share them
+	for i, decl := range decls {
+		slot := decl.Binding.Slot
+		if !s.captured[slot] {
+			continue
+		}
+		if i < parameters {
+			c.emit(code.OpGetLocal, s.base+slot)
+			c.emit(code.OpNewCell, s.base+slot)
+			c.emit(code.OpSetCell, s.base+slot)
+		} else {
+			c.emit(code.OpNewCell, s.base+slot)
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) leaveScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+// the names a list of statements declares directly, in order
+func declarations(statements []ast.Statement) []*ast.Identifier {
+	var decls []*ast.Identifier
+	for _, s := range statements {
+		switch s := s.(type) {
+		case *ast.LetStatement:
+			decls = append(decls, s.Name)
+		case *ast.FunctionStatement:
+			decls = append(decls, s.Name)
+		}
+	}
+	return decls
+}
+
+func (c *Compiler) locate(ident *ast.Identifier) (location, int, error) {
+	b := ident.Binding
+	if b == nil {
+		return 0, 0, fmt.Errorf("%s: undefined identifier: %s", ident.Pos(), ident.Value)
+	}
+	if b.IsBuiltin() {
+		return locationBuiltin, b.Slot, nil
+	}
+	if b.Depth >= len(c.scopes) {
+		return 0, 0, fmt.Errorf("%s: %s is bound outside of the program", ident.Pos(), ident.Value)
+	}
+	s := c.scopes[len(c.scopes)-1-b.Depth]
+	switch {
+	case s.unit == nil && b.Slot >= maxGlobals:
+		return 0, 0, fmt.Errorf("%s: too many global variables", ident.Pos())
+	case s.unit == nil:
+		return locationGlobal, b.Slot, nil
+	case s.unit == c.currentUnit() && s.captured[b.Slot]:
+		return locationCell, s.base + b.Slot, nil
+	case s.unit == c.currentUnit():
+		return locationLocal, s.base + b.Slot, nil
+	}
+	index, err := c.freeIndex(len(c.units)-1, freeVariable{scope: s, slot: b.Slot})
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %s", ident.Pos(), err)
+	}
+	return locationFree, index, nil
+}
+
+// index of v among the free variables of the function compiled by units[u]
+func (c *Compiler) freeIndex(u int, v freeVariable) (int, error) {
+	fn := c.units[u]
+	for i, free := range fn.free {
+		if free == v {
+			return i, nil
+		}
+	}
+	if len(fn.free) == maxLocals {
+		return 0, fmt.Errorf("too many free variables")
+	}
+	fn.free = append(fn.free, v)
+	return len(fn.free) - 1, nil
+}
+
+// pushes the value of the variable, a global or a cell can be read before it is
+// set by a function declared before the variable, the error of the vm names it
+func (c *Compiler) load(ident *ast.Identifier) error {
+	where, index, err := c.locate(ident)
+	if err != nil {
+		return err
+	}
+	var name int
+	if where == locationGlobal || where == locationCell || where == locationFree {
+		if name, err = c.name(ident); err != nil {
+			return err
+		}
+	}
+	switch where {
+	case locationGlobal:
+		c.emit(code.OpGetGlobal, index, name)
+	case locationCell:
+		c.emit(code.OpGetCell, index, name)
+	case locationFree:
+		c.emit(code.OpGetFree, index, name)
+	case locationLocal:
+		c.emit(code.OpGetLocal, index)
+	case locationBuiltin:
+		c.emit(code.OpGetBuiltin, index)
+	}
+	return nil
+}
+
+// pops the value on top of the stack into the variable
+func (c *Compiler) store(ident *ast.Identifier) error {
+	where, index, err := c.locate(ident)
+	if err != nil {
+		return err
+	}
+	switch where {
+	case locationGlobal:
+		c.emit(code.OpSetGlobal, index)
+	case locationLocal:
+		c.emit(code.OpSetLocal, index)
+	case locationCell:
+		c.emit(code.OpSetCell, index)
+	case locationFree:
+		c.emit(code.OpSetFree, index)
+	case locationBuiltin:
+		return fmt.Errorf("%s: cannot assign to the builtin %s", ident.Pos(), ident.Value)
+	}
+	return nil
+}
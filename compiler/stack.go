@@ -0,0 +1,36 @@
+package compiler
+
+import "github.com/eyanshu1997/yacgo/code"
+
+// how many values an instruction leaves on the operand stack minus how many it
+// takes, for the code that runs after it. a jump leaves the stack as it is
+func stackEffect(op code.Opcode, operands []int) int {
+	switch op {
+	case code.OpConstant, code.OpNull, code.OpTrue, code.OpFalse, code.OpVoid,
+		code.OpGetGlobal, code.OpGetLocal, code.OpGetCell, code.OpLoadCell,
+		code.OpGetFree, code.OpLoadFree, code.OpGetBuiltin, code.OpIterNext:
+		return 1
+	case code.OpMinus, code.OpBang, code.OpBitNot, code.OpJump, code.OpNewCell, code.OpIter:
+		return 0
+	case code.OpArray, code.OpHash:
+		return 1 - operands[0]
+	case code.OpCall:
+		return -operands[0]
+	case code.OpClosure:
+		return 1 - operands[1]
+	}
+	// the binary operators, the stores, OpPop, OpJumpNotTruthy, OpIndex and OpReturnValue
+	return -1
+}
+
+// pops the values pushed since the stack had depth values, for a jump out of
+// the middle of an expression. the code after the jump is not run, so the
+// depth it is compiled with stays the same
+func (c *Compiler) popTo(depth int) {
+	u := c.currentUnit()
+	saved := u.depth
+	for u.depth > depth {
+		c.emit(code.OpPop)
+	}
+	u.depth = saved
+}
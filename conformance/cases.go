@@ -0,0 +1,130 @@
+package conformance
+
+// Case is a program and what running it gives: the Inspect of its value,
+// "ERROR: " and the message for a runtime error or "" when it has no value
+type Case struct {
+	Name     string
+	Input    string
+	Expected string
+}
+
+// Cases every backend has to agree on
+var Cases = []Case{
+	// values
+	{"integer", "5", "5"},
+	{"float", "2.5", "2.5"},
+	{"string", `"héllo"`, "héllo"},
+	{"booleans", "true; false", "false"},
+	{"array", `[1, "two", 3.0, [true]]`, "[1, two, 3.0, [true]]"},
+	{"hash", `{"a": 1, 2: "b", true: [3]}`, "{a: 1, 2: b, true: [3]}"},
+	{"empty program", "", ""},
+	{"let has no value", "let a = 1;", ""},
+	{"last statement wins", "1; 2; 3", "3"},
+
+	// operators
+	{"integer arithmetic", "(5 + 10 * 2 + 15 / 3) * 2 + -10", "50"},
+	{"integer division truncates", "7 / 2", "3"},
+	{"mixed arithmetic", "7 / 2.0 + 1", "4.5"},
+	{"modulo", "-7 % 3", "-1"},
+	{"power", "2 ** 3 ** 2", "512"},
+	{"float power", "2 ** 0.5 * 2 ** 0.5", "2.0000000000000004"},
+	{"bitwise", "(12 & 10) | (1 ^ 3) << 4 | ~0 & 1 >> 1", "40"},
+	{"comparisons", "[1 < 2, 2 <= 2, 3 > 4, 4 >= 5, 1 == 1.0, \"a\" != \"b\"]", "[true, true, false, false, true, true]"},
+	{"string concatenation", `"foo" + "bar"`, "foobar"},
+	{"prefix", "[!true, !!5, -(-3), -1.5, ~5]", "[false, true, 3, -1.5, -6]"},
+	{"and short circuits", "let n = 0; let f = fn() { n = n + 1; true }; false && f(); n", "0"},
+	{"or short circuits", "let n = 0; let f = fn() { n = n + 1; true }; true || f(); n", "0"},
+	{"logical results are booleans", "[1 && \"x\", 0 || 2, false || false && 1]", "[true, true, false]"},
+
+	// indexing
+	{"array index", "[1, 2, 3][1] + [1, 2, 3][-1]", "5"},
+	{"hash index", `let h = {"one": 1, "two": 2}; h["one"] + h["two"]`, "3"},
+	{"missing key", `{"a": 1}["b"]`, "null"},
+
+	// conditionals
+	{"if statement value", "if (1 < 2) { 10 } else { 20 }", "10"},
+	{"if without branch taken", "if (false) { 10 }", "null"},
+	{"if branch without value", "if (true) { let a = 1; }", ""},
+	{"empty if branch", "if (true) {}", ""},
+	{"else if", "let x = 5; if (x < 0) { 1 } else if (x < 10) { 2 } else { 3 }", "2"},
+	{"if expression", "let v = if (false) { 1 } else if (true) { 2 }; v * 10", "20"},
+	{"if expression without value", "let v = if (true) { let a = 1; }; v", "null"},
+	{"if expression in an operand", "1 + if (false) { 10 } else { 20 } * 2", "41"},
+	{"block scopes", "let a = 1; if (true) { let a = 2; a = a + 1; } a", "1"},
+
+	// functions
+	{"function statement value", "fn add(a, b) { a + b } add", "fn add(a, b) {\n(a + b)\n}"},
+	{"function literal values", "let f = fn(x) { x * 2 }; [f, fn() { }]", "[fn(x) {\n(x * 2)\n}, fn() {\n\n}]"},
+	{"call", "let add = fn(a, b) { a + b }; add(1, 2)", "3"},
+	{"function statement", "fn double(x) { return x * 2; } double(21)", "42"},
+	{"implicit return", "fn f(x) { if (x) { 1 } else { 2 } } f(false)", "2"},
+	{"continue in an if expression", "let r = 0; for k in [1, 2, 3] { r = r + if (k == 2) { continue; } else { k }; } r", "4"},
+	{"break in an if expression", "let i = 0; let s = 0; while (i < 5) { i = i + 1; let x = if (i == 3) { break; } else { i }; s = s + 1; } s", "2"},
+	{"break from an inner loop in an expression", "let t = 0; for x in [1, 2] { for y in [1, 2] { t = t + [if (y == 2) { break; } else { y }][0]; } } t", "2"},
+	{"continue deep in an expression", "let n = 0; for x in [1, 2, 3] { n = n + len([1, {\"k\": if (x == 2) { continue; } else { \"ab\" }}[\"k\"]][1]); } n", "4"},
+	{"break in a condition", "let i = 0; while (true) { i = i + 1; if (if (i > 2) { break; } else { false }) { i = 100; } } i", "3"},
+	{"return from an expression in a loop", "fn f() { for x in [1, 2] { let y = 10 + if (x == 2) { return x; } else { 0 }; } } f()", "2"},
+	{"return from an if expression", "let f = fn(a) { let x = if (a) { return 5; } else { 2 }; x + 100 }; [f(true), f(false)]", "[5, 102]"},
+	{"implicit null return", "fn f() { let a = 1; } f()", "null"},
+	{"early return", "fn f(x) { if (x > 0) { return 1; } return -1; } f(5) + f(-5)", "0"},
+	{"top level return", "return 7; 8", "7"},
+	{"recursion", "fn fib(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) } fib(15)", "610"},
+	{"local recursion", "let f = fn() { let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(6) }; f()", "720"},
+	{"mutual recursion", `let outer = fn() {
+	let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };
+	let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };
+	even(10)
+}; outer()`, "true"},
+	{"first class functions", "let apply = fn(f, x) { f(x) }; apply(fn(y) { y * 3 }, 4)", "12"},
+	{"builtins", `[len("héllo"), len([1, 2]), len({"a": 1}), len(push([1], 2))]`, "[5, 2, 1, 2]"},
+	{"builtin as a value", "let l = len; l([1, 2, 3])", "3"},
+	{"shadowed builtin", "let len = fn(x) { 42 }; len([])", "42"},
+
+	// closures
+	{"closure", "let newAdder = fn(x) { fn(y) { x + y } }; newAdder(2)(3)", "5"},
+	{"currying", "let add = fn(a) { fn(b) { fn(c) { a + b + c } } }; add(1)(2)(3)", "6"},
+	{"counter", "let counter = fn() { let n = 0; fn() { n = n + 1; n } }; let c = counter(); c(); c(); c()", "3"},
+	{"independent counters", "let counter = fn() { let n = 0; fn() { n = n + 1; n } }; let a = counter(); let b = counter(); a(); a(); b(); a() * 10 + b()", "32"},
+	{"shared variable", "let pair = fn() { let n = 0; [fn() { n = n + 1; }, fn() { n }] }; let p = pair(); p[0](); p[0](); p[1]()", "2"},
+	{"captured parameter", "let f = fn(x) { let g = fn() { x = x * 2; x }; g(); g() }; f(3)", "12"},
+	{"nested capture", "let f = fn(a) { fn(b) { fn(c) { a = a + 1; a + b + c } } }; let g = f(1)(10); g(100); g(100)", "113"},
+	{"global from a closure", "let total = 0; let add = fn(x) { total = total + x; }; add(2); add(5); total", "7"},
+	{"closure in a block", "let f = 0; if (true) { let x = 5; f = fn() { x }; } f()", "5"},
+
+	// loops
+	{"while", "let i = 0; let s = 0; while (i < 5) { i = i + 1; s = s + i; } s", "15"},
+	{"while has no value", "let i = 0; while (i < 3) { i = i + 1; }", ""},
+	{"for over an array", "let s = 0; for x in [1, 2, 3] { s = s + x; } s", "6"},
+	{"for over a string", `let s = ""; for c in "abc" { s = c + s; } s`, "cba"},
+	{"for over a hash", `let s = ""; for k in {"b": 1, "a": 2} { s = s + k; } s`, "ba"},
+	{"break", "let i = 0; while (true) { i = i + 1; if (i == 3) { break; } } i", "3"},
+	{"continue", "let s = 0; for x in [1, 2, 3, 4, 5] { if (x % 2 == 0) { continue; } s = s + x; } s", "9"},
+	{"nested loops", "let n = 0; for a in [1, 2, 3] { for b in [1, 2, 3] { if (b > a) { break; } n = n + 1; } } n", "6"},
+	{"return from a loop", "fn find(xs, v) { for x in xs { if (x == v) { return true; } } false } [find([1, 2], 2), find([1, 2], 3)]", "[true, false]"},
+	{"closures per iteration", "let fs = []; for x in [1, 2, 3] { fs = push(fs, fn() { x }); } fs[0]() + fs[1]() * 10 + fs[2]() * 100", "321"},
+	{"closures per while iteration", "let fs = []; let i = 0; while (i < 2) { let j = i; fs = push(fs, fn() { j }); i = i + 1; } fs[0]() * 10 + fs[1]()", "1"},
+	{"loop in a function", "fn sum(n) { let s = 0; let i = 1; while (i <= n) { s = s + i; i = i + 1; } s } sum(100)", "5050"},
+
+	// errors
+	{"type mismatch", "5 + true; 5", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+	{"unknown operator", "true + false", "ERROR: unknown operator: BOOLEAN + BOOLEAN"},
+	{"unknown prefix", "-true", "ERROR: unknown operator: -BOOLEAN"},
+	{"division by zero", "1 / 0", "ERROR: division by zero"},
+	{"modulo by zero", "1 % 0", "ERROR: modulo by zero"},
+	{"negative shift", "1 << -1", "ERROR: negative shift count: -1"},
+	{"error stops the program", "let a = 1; a = a - \"x\"; a", "ERROR: type mismatch: INTEGER - STRING"},
+	{"error in a function", "let f = fn() { return 1 + [1]; }; f(); 5", "ERROR: type mismatch: INTEGER + ARRAY"},
+	{"error in a loop", "for x in [1, 2] { x + true; }", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+	{"index out of range", "[1, 2][5]", "ERROR: index out of range: 5 with length 2"},
+	{"unusable hash key", "{[1]: 2}", "ERROR: unusable as hash key: ARRAY"},
+	{"unsupported index", "5[0]", "ERROR: index operator not supported: INTEGER[INTEGER]"},
+	{"wrong number of arguments", "fn(a) { a }(1, 2)", "ERROR: wrong number of arguments: want=1, got=2"},
+	{"global used before it is set", "let f = fn() { y }; let r = f(); let y = 1; r", "ERROR: identifier not found: y"},
+	{"local used before it is set", "let g = fn() { let h = fn() { v }; let r = h(); let v = 1; r }; g()", "ERROR: identifier not found: v"},
+	{"free variable used before it is set", "let g = fn() { let h = fn() { fn() { v }() }; let r = h(); let v = 1; r }; g()", "ERROR: identifier not found: v"},
+	{"stack overflow", "fn f(n) { f(n + 1) } f(0)", "ERROR: stack overflow"},
+	{"deep recursion with arguments", "fn f(n, a, b) { if (n == 0) { 0 } else { f(n - 1, a, b) + 1 } } f(600, 1, 2)", "600"},
+	{"not a function", "5(1)", "ERROR: not a function: INTEGER"},
+	{"builtin error", "len(1)", "ERROR: argument to `len` not supported, got INTEGER"},
+	{"not iterable", "for x in 5 { }", "ERROR: cannot iterate over INTEGER"},
+}
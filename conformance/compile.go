@@ -0,0 +1,44 @@
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/compiler"
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/parser"
+	"github.com/eyanshu1997/yacgo/resolver"
+)
+
+// Parse parses and resolves a program like the repl does before running it,
+// the first error of either step is returned
+func Parse(input string) (*ast.Program, error) {
+	return ParseWith(resolver.NewResolver(), input)
+}
+
+// ParseWith resolves with r, which knows the globals of the programs it
+// resolved before
+func ParseWith(r *resolver.Resolver, input string) (*ast.Program, error) {
+	p := parser.NewParser(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		return nil, fmt.Errorf("parser error in %q: %s", input, errors[0])
+	}
+	if errors := r.Resolve(program); len(errors) != 0 {
+		return nil, fmt.Errorf("resolver error in %q: %s", input, errors[0])
+	}
+	return program, nil
+}
+
+// Compile parses, resolves and compiles a program
+func Compile(input string) (*compiler.Bytecode, error) {
+	program, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		return nil, fmt.Errorf("compiler error in %q: %s", input, err)
+	}
+	return c.Bytecode(), nil
+}
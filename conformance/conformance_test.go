@@ -0,0 +1,61 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/evaluator"
+	"github.com/eyanshu1997/yacgo/object"
+	"github.com/eyanshu1997/yacgo/vm"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	program, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return ""
+	}
+	return obj.Inspect()
+}
+
+func evaluate(t *testing.T, input string) string {
+	return inspect(evaluator.Eval(parse(t, input), object.NewEnvironment()))
+}
+
+func execute(t *testing.T, input string) string {
+	bytecode, err := Compile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	machine := vm.New(bytecode)
+	if err := machine.Run(); err != nil {
+		return "ERROR: " + err.Error()
+	}
+	return inspect(machine.Result())
+}
+
+func TestEvaluator(t *testing.T) {
+	for _, tt := range Cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := evaluate(t, tt.Input); got != tt.Expected {
+				t.Errorf("wrong result for %q.\nexpected=%q\ngot=     %q", tt.Input, tt.Expected, got)
+			}
+		})
+	}
+}
+
+func TestVM(t *testing.T) {
+	for _, tt := range Cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := execute(t, tt.Input); got != tt.Expected {
+				t.Errorf("wrong result for %q.\nexpected=%q\ngot=     %q", tt.Input, tt.Expected, got)
+			}
+		})
+	}
+}
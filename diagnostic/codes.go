@@ -0,0 +1,29 @@
+package diagnostic
+
+// Code identifies the kind of a diagnostic so tools can tell them apart
+type Code string
+
+const (
+	// parser
+	CodeUnexpectedToken Code = "P001"
+	CodeNoPrefixParseFn Code = "P002"
+	CodeInvalidInteger  Code = "P003"
+	CodeExpectedStmt    Code = "P004"
+	CodeInvalidFloat    Code = "P005"
+	CodeOutsideLoop     Code = "P006"
+
+	// resolver
+	CodeUndefined        Code = "R001"
+	CodeDuplicateDecl    Code = "R002"
+	CodeUndeclaredAssign Code = "R003"
+	CodeBuiltinAssign    Code = "R004"
+
+	// lexer
+	CodeIllegalCharacter    Code = "L001"
+	CodeUnterminatedString  Code = "L002"
+	CodeInvalidEscape       Code = "L003"
+	CodeUnterminatedComment Code = "L004"
+	CodeInvalidUTF8         Code = "L005"
+	CodeReadError           Code = "L006"
+	CodeInvalidNumber       Code = "L007"
+)
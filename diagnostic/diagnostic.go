@@ -0,0 +1,55 @@
+package diagnostic
+
+import (
+	"fmt"
+
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Diagnostic is a problem found in the source, located by its span
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	Start    tokens.Position
+	End      tokens.Position
+	Message  string
+	Expected []tokens.TokenType // token types that would have been accepted, if any
+	Found    tokens.TokenType   // token type that was found instead, if any
+	Hint     string             // optional suggestion on how to fix it
+}
+
+// file:line:col: message
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s", d.Start, d.Message)
+}
+
+func NewError(code Code, start, end tokens.Position, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Severity: SeverityError,
+		Code:     code,
+		Start:    start,
+		End:      end,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,81 @@
+package diagnostic
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Render formats the diagnostic with the offending source line and a caret underline
+//
+//	error[P001]: expected next token to be =, got INT instead
+//	 --> test.yapl:2:7
+//	  |
+//	2 | let y 10;
+//	  |       ^^
+func Render(d *Diagnostic, source string) string {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s[%s]: %s\n", d.Severity, d.Code, d.Message)
+	fmt.Fprintf(&out, " --> %s\n", d.Start)
+	line, ok := sourceLine(source, d.Start.Line)
+	if ok && d.Start.IsValid() {
+		gutter := fmt.Sprintf("%d", d.Start.Line)
+		pad := strings.Repeat(" ", len(gutter))
+		fmt.Fprintf(&out, "%s |\n", pad)
+		fmt.Fprintf(&out, "%s | %s\n", gutter, line)
+		fmt.Fprintf(&out, "%s | %s%s\n", pad, caretIndent(line, d.Start.Column), carets(d, line))
+	}
+	if d.Hint != "" {
+		fmt.Fprintf(&out, " = hint: %s\n", d.Hint)
+	}
+	return out.String()
+}
+
+func RenderAll(diags []*Diagnostic, source string) string {
+	var out bytes.Buffer
+	for _, d := range diags {
+		out.WriteString(Render(d, source))
+	}
+	return out.String()
+}
+
+func sourceLine(source string, line int) (string, bool) {
+	if line < 1 {
+		return "", false
+	}
+	lines := strings.Split(source, "\n")
+	if line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// keeps tabs from the source line so the caret lines up with the offending column,
+// columns count runes
+func caretIndent(line string, column int) string {
+	var out bytes.Buffer
+	runes := []rune(line)
+	for i := 0; i < column-1; i++ {
+		if i < len(runes) && runes[i] == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+	}
+	return out.String()
+}
+
+// one caret per character of the span, cut at the end of the line and at least one
+func carets(d *Diagnostic, line string) string {
+	width := 1
+	if d.End.Line == d.Start.Line && d.End.Column > d.Start.Column {
+		width = d.End.Column - d.Start.Column
+	} else if length := utf8.RuneCountInString(line); d.End.Line > d.Start.Line && length >= d.Start.Column {
+		width = length - d.Start.Column + 1
+	}
+	if width < 1 {
+		width = 1
+	}
+	return strings.Repeat("^", width)
+}
@@ -0,0 +1,51 @@
+package diagnostic
+
+import (
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+func TestRender(t *testing.T) {
+	source := "let x = 5;\n\tlet y 10;"
+	d := NewError(CodeUnexpectedToken,
+		tokens.Position{File: "a.yapl", Line: 2, Column: 8, Offset: 18},
+		tokens.Position{File: "a.yapl", Line: 2, Column: 10, Offset: 20},
+		"expected next token to be =, got INT instead")
+	d.Hint = "add the missing ="
+	expected := "error[P001]: expected next token to be =, got INT instead\n" +
+		" --> a.yapl:2:8\n" +
+		"  |\n" +
+		"2 | \tlet y 10;\n" +
+		"  | \t      ^^\n" +
+		" = hint: add the missing =\n"
+	if got := Render(d, source); got != expected {
+		t.Errorf("Render wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+	if d.Error() != "a.yapl:2:8: expected next token to be =, got INT instead" {
+		t.Errorf("Error wrong. got=%q", d.Error())
+	}
+}
+
+func TestRenderWithoutSource(t *testing.T) {
+	d := NewError(CodeNoPrefixParseFn, tokens.Position{Line: 3, Column: 1}, tokens.Position{Line: 3, Column: 2},
+		"no prefix parse function for ; found")
+	expected := "error[P002]: no prefix parse function for ; found\n --> 3:1\n"
+	if got := Render(d, "x"); got != expected {
+		t.Errorf("Render wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestRenderCountsRunes(t *testing.T) {
+	source := "let größe 1;"
+	d := NewError(CodeUnexpectedToken, tokens.Position{Line: 1, Column: 11, Offset: 13}, tokens.Position{Line: 1, Column: 12, Offset: 14},
+		"expected next token to be =, got INT instead")
+	expected := "error[P001]: expected next token to be =, got INT instead\n" +
+		" --> 1:11\n" +
+		"  |\n" +
+		"1 | let größe 1;\n" +
+		"  |           ^\n"
+	if got := Render(d, source); got != expected {
+		t.Errorf("Render wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
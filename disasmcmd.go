@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/eyanshu1997/yacgo/compiler"
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/parser"
+	"github.com/eyanshu1997/yacgo/resolver"
+)
+
+// runDisasm prints the bytecode of a file, or of stdin, and returns the exit code
+func runDisasm(args []string) int {
+	flags := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: yacgo disasm [file]\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	name := "<stdin>"
+	var source []byte
+	var err error
+	if flags.NArg() == 0 {
+		source, err = io.ReadAll(os.Stdin)
+	} else {
+		name = flags.Arg(0)
+		source, err = os.ReadFile(name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "disasm: %s\n", err)
+		return 1
+	}
+	bytecode, code := compileSource(name, string(source))
+	if bytecode == nil {
+		return code
+	}
+	io.WriteString(os.Stdout, bytecode.String())
+	return 0
+}
+
+// compileSource parses, resolves and compiles a program, printing the errors
+// of whichever step fails and returning nil with the exit code
+func compileSource(name, source string) (*compiler.Bytecode, int) {
+	p := parser.NewParser(lexer.NewLexer(source, lexer.WithFilename(name)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		io.WriteString(os.Stderr, diagnostic.RenderAll(p.Errors(), source))
+		return nil, 1
+	}
+	if errors := resolver.Resolve(program); len(errors) != 0 {
+		io.WriteString(os.Stderr, diagnostic.RenderAll(errors, source))
+		return nil, 1
+	}
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+		return nil, 1
+	}
+	return c.Bytecode(), 0
+}
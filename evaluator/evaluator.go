@@ -0,0 +1,263 @@
+package evaluator
+
+import (
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+// most nested function calls, a deeper call is a stack overflow error instead
+// of a crash of the interpreter
+const MaxCallDepth = 1024
+
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	log.Printf("Eval called for node [%T] %s", node, node)
+	switch node := node.(type) {
+	// statements
+	case *ast.Program:
+		return evalProgram(node, env)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+	case *ast.LetStatement:
+		val := Eval(node.Value, env)
+		if isAbrupt(val) {
+			return val
+		}
+		env.Set(node.Name.Value, val)
+	case *ast.AssignmentStatement:
+		return evalAssignmentStatement(node, env)
+	case *ast.ReturnStatement:
+		val := Eval(node.ReturnValue, env)
+		if isAbrupt(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.IfStatement:
+		return evalConditional(node.Condition, node.Consequence, node.Alternative, env)
+	case *ast.FunctionStatement:
+		fn := &object.Function{Name: node.Name.Value, Parameters: node.Parameters, Body: node.Body, Env: env}
+		env.Set(node.Name.Value, fn)
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return object.BREAK
+	case *ast.ContinueStatement:
+		return object.CONTINUE
+	case *ast.BadStatement:
+		return object.NewError("%s: cannot evaluate a statement with syntax errors", node.Pos())
+
+	// expressions
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.IfExpression:
+		result := evalConditional(node.Condition, node.Consequence, node.Alternative, env)
+		if result == nil {
+			// an empty branch or one ending in a let has no value
+			return object.NULL
+		}
+		return result
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.PrefixExpression:
+		right := Eval(node.Right, env)
+		if isAbrupt(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+	case *ast.InfixExpression:
+		left := Eval(node.Left, env)
+		if isAbrupt(left) {
+			return left
+		}
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, left, env)
+		}
+		right := Eval(node.Right, env)
+		if isAbrupt(right) {
+			return right
+		}
+		return evalInfixExpression(node.Operator, left, right)
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isAbrupt(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isAbrupt(left) {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isAbrupt(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+	case *ast.FunctionLiteral:
+		return &object.Function{Parameters: node.Parameters, Body: node.Body, Env: env}
+	case *ast.BadExpression:
+		return object.NewError("%s: cannot evaluate an expression with syntax errors", node.Pos())
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isAbrupt(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isAbrupt(args[0]) {
+			return args[0]
+		}
+		return applyFunction(function, args, env)
+	}
+	return nil
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+	for _, statement := range program.Statements {
+		result = Eval(statement, env)
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+	return result
+}
+
+// unlike evalProgram the return value is not unwrapped so it can stop the enclosing blocks too,
+// break and continue stop them up to the loop
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+		if result != nil {
+			switch result.Type() {
+			case object.ObjectTypeReturnValue, object.ObjectTypeError, object.ObjectTypeBreak, object.ObjectTypeContinue:
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// assignment updates the nearest existing binding, it never declares a variable
+func evalAssignmentStatement(as *ast.AssignmentStatement, env *object.Environment) object.Object {
+	name := as.Name.Value
+	val := Eval(as.Value, env)
+	if isAbrupt(val) {
+		return val
+	}
+	if !env.Assign(name, val) {
+		return object.NewError("assignment to undeclared identifier: %s", name)
+	}
+	return nil
+}
+
+// if statements and if expressions, the value is the one of the branch taken
+func evalConditional(condition ast.Expression, consequence, alternative *ast.BlockStatement, env *object.Environment) object.Object {
+	evaluated := Eval(condition, env)
+	if isAbrupt(evaluated) {
+		return evaluated
+	}
+	if isTruthy(evaluated) {
+		return Eval(consequence, object.NewEnclosedEnvironment(env))
+	} else if alternative != nil {
+		return Eval(alternative, object.NewEnclosedEnvironment(env))
+	}
+	return object.NULL
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	if builtin := object.GetBuiltinByName(node.Value); builtin != nil {
+		return builtin
+	}
+	return object.NewError("identifier not found: %s", node.Value)
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
+		if isAbrupt(key) {
+			return key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return object.NewError("unusable as hash key: %s", key.Type())
+		}
+		value := Eval(pair.Value, env)
+		if isAbrupt(value) {
+			return value
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash
+}
+
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isAbrupt(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+// calls fn from the code running in caller
+func applyFunction(fn object.Object, args []object.Object, caller *object.Environment) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return object.NewError("wrong number of arguments: want=%d, got=%d",
+				len(fn.Parameters), len(args))
+		}
+		if caller.Depth() >= MaxCallDepth {
+			return object.NewError("stack overflow")
+		}
+		extendedEnv := extendFunctionEnv(fn, args, caller)
+		evaluated := Eval(fn.Body, extendedEnv)
+		return unwrapReturnValue(evaluated)
+	case *object.Builtin:
+		return fn.Fn(args...)
+	default:
+		return object.NewError("not a function: %s", fn.Type())
+	}
+}
+
+func extendFunctionEnv(fn *object.Function, args []object.Object, caller *object.Environment) *object.Environment {
+	env := object.NewCallEnvironment(fn.Env, caller)
+	for i, param := range fn.Parameters {
+		env.Set(param.Value, args[i])
+	}
+	return env
+}
+
+// a return only leaves the function it is in
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	if obj == nil {
+		return object.NULL
+	}
+	return obj
+}
@@ -0,0 +1,844 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/object"
+	"github.com/eyanshu1997/yacgo/parser"
+)
+
+func testEval(t *testing.T, input string) object.Object {
+	l := lexer.NewLexer(input)
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+	for _, msg := range p.Errors() {
+		t.Fatalf("parser error for %q: %q", input, msg)
+	}
+	env := object.NewEnvironment()
+	return Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d",
+			result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t",
+			result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != object.NULL {
+		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a", 5},
+		{"let a = -10; a", -10},
+		{"let a = 5 + 5 + 5 + 5 - 10; a", 10},
+		{"let a = 2 * 2 * 2 * 2 * 2; a", 32},
+		{"let a = -50 + 100 + -50; a", 0},
+		{"let a = 20 + 2 * -10; a", 0},
+		{"let a = 50 / 2 * 2 + 10; a", 60},
+		{"let a = 3 * (3 * 3) + 10; a", 37},
+		{"let a = (5 + 10 * 2 + 15 / 3) * 2 + -10; a", 50},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let a = true; a", true},
+		{"let a = false; a", false},
+		{"let a = 1 < 2; a", true},
+		{"let a = 1 > 2; a", false},
+		{"let a = 1 == 1; a", true},
+		{"let a = 1 != 1; a", false},
+		{"let a = true == true; a", true},
+		{"let a = true != false; a", true},
+		{"let a = (1 < 2) == true; a", true},
+		{"let a = (1 > 2) == true; a", false},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestExclaimOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let a = !true; a", false},
+		{"let a = !false; a", true},
+		{"let a = !5; a", false},
+		{"let a = !!true; a", true},
+		{"let a = !!5; a", true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIfStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { return 10; }", 10},
+		{"if (false) { return 10; }", nil},
+		{"if (1 < 2) { return 10; }", 10},
+		{"if (1 > 2) { return 10; } else { return 20; }", 20},
+		{"let x = 1; if (x < 2) { x = 5; } x", 5},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10;", 10},
+		{"return 10; let a = 9;", 10},
+		{"return 2 * 5; let a = 9;", 10},
+		{"let a = 9; return 2 * 5; a = 9;", 10},
+		{`if (10 > 1) {
+			if (10 > 1) {
+				return 10;
+			}
+			return 1;
+		}`, 10},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"return 5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"return 5 + true; return 5;", "type mismatch: INTEGER + BOOLEAN"},
+		{"return -true;", "unknown operator: -BOOLEAN"},
+		{"return true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) { return true + false; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"return foobar;", "identifier not found: foobar"},
+		{"foobar = 5;", "assignment to undeclared identifier: foobar"},
+		{"return 5 / 0;", "division by zero"},
+		{"let a = 5; a(1);", "not a function: INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a", 5},
+		{"let a = 5 * 5; a", 25},
+		{"let a = 5; let b = a; b", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c", 15},
+		{"let a = 5; a = a * 2; a", 10},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestBuiltinCall(t *testing.T) {
+	evaluated := testEval(t, "return puts(1);")
+	testNullObject(t, evaluated)
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "let f = fn(x) { return x + 2; }; f"
+	evaluated := testEval(t, input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
+	}
+	if fn.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
+	}
+	expectedBody := "return (x + 2);"
+	if fn.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { return x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { return x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { return x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn add(a, b) { return a + b; } add(2, 3)", 5},
+		{"fn fact(n) { if (n < 2) { return 1; } return n * fact(n - 1); } fact(5)", 120},
+		{"let r = fn(x) { return x; }(5); r", 5},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestWrongNumberOfArguments(t *testing.T) {
+	evaluated := testEval(t, "let f = fn(a, b) { return a; }; f(1);")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "wrong number of arguments: want=2, got=1" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	tests := []string{
+		"fn f(n) { f(n + 1) } f(0)",
+		"let f = fn(n) { let g = fn() { f(n + 1) }; g() }; f(0)",
+	}
+	for _, input := range tests {
+		errObj, ok := testEval(t, input).(*object.Error)
+		if !ok || errObj.Message != "stack overflow" {
+			t.Errorf("unbounded recursion should be a stack overflow error for %q. got=%v", input, testEval(t, input))
+		}
+	}
+	testIntegerObject(t, testEval(t, "fn sum(n) { if (n == 0) { 0 } else { n + sum(n - 1) } } sum(1000)"), 500500)
+}
+
+func TestExpressionStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"5 + 5;", 10},
+		{"-(2 * 3)", -6},
+		{"let add = fn(a, b) { a + b }; add(1, 2);", 3},
+		{"fn(x) { x * 2 }(4)", 8},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	evaluated := testEval(t, `"Hello World!"`)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`let name = "YAL"; "hi " + name`, "hi YAL"},
+		{`"a\tb" + "\u{21}"`, "a\tb!"},
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+		{`"a" - "b"`, "unknown operator: STRING - STRING"},
+		{`"a" + 1`, "type mismatch: STRING + INTEGER"},
+		{`len("héllo")`, 5},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, obj.Message)
+				}
+			default:
+				t.Errorf("unexpected object %T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	evaluated := testEval(t, "[1, 2 * 2, 3 + 3]")
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d", len(result.Elements))
+	}
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[1, 2, 3][3]", "index out of range: 3 with length 3"},
+		{"[1, 2, 3][-4]", "index out of range: -4 with length 3"},
+		{"[][0]", "index out of range: 0 with length 0"},
+		{"1[0]", "index operator not supported: INTEGER[INTEGER]"},
+		{`[1]["a"]`, "index operator not supported: ARRAY[STRING]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"len([1, 2, 3])", "3"},
+		{"len([])", "0"},
+		{"push([1, 2], 3)", "[1, 2, 3]"},
+		{"let a = [1]; let b = push(a, 2); a", "[1]"},
+		{"push(1, 2)", "ERROR: argument to `push` must be ARRAY, got INTEGER"},
+		{"len(1)", "ERROR: argument to `len` not supported, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+	evaluated := testEval(t, input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		object.TRUE.HashKey():                      5,
+		object.FALSE.HashKey():                     6,
+	}
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+		}
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+	if result.Inspect() != "{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}" {
+		t.Errorf("Inspect not in insertion order. got=%q", result.Inspect())
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{1: 5}[true]`, nil},
+		{`len({"a": 1, "b": 2, "a": 3})`, 2},
+		{`{"a": 1, "a": 3}["a"]`, 3},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestUnhashableKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"name": "yal"}[fn(x) { x }];`, "unusable as hash key: FUNCTION"},
+		{`{[1]: 2}`, "unusable as hash key: ARRAY"},
+		{`{{}: 2}`, "unusable as hash key: HASH"},
+		{`{"a": 1}[[1]]`, "unusable as hash key: ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestUnicodeIdentifiersAndStrings(t *testing.T) {
+	evaluated := testEval(t, `let größe = "😀"; let π = 3; len(größe + "ü") + π`)
+	testIntegerObject(t, evaluated, 5)
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 + 1", 1.5},
+		{"2 * 1.25", 2.5},
+		{"1.0 / 4", 0.25},
+		{"7 / 2.0", 3.5},
+		{"1e3 - 1", 999.0},
+		{"0x10 * 0.5", 8.0},
+	}
+	for _, tt := range tests {
+		testFloatObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestMixedNumberRules(t *testing.T) {
+	// integers stay integers, integer division truncates
+	testIntegerObject(t, testEval(t, "7 / 2"), 3)
+	testIntegerObject(t, testEval(t, "0b1010 + 0o17 + 1_000"), 1025)
+
+	comparisons := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 == 1.0", true},
+		{"1.0 != 1", false},
+		{"0.1 + 0.2 > 0.3", true},
+		{"2 < 2.5", true},
+		{"2.5 < 2", false},
+	}
+	for _, tt := range comparisons {
+		testBooleanObject(t, testEval(t, tt.input), tt.expected)
+	}
+
+	inspected := []struct {
+		input    string
+		expected string
+	}{
+		{"3.0", "3.0"},
+		{"1.5 * 2", "3.0"},
+		{"1e21", "1e+21"},
+		{"0.1", "0.1"},
+	}
+	for _, tt := range inspected {
+		if got := testEval(t, tt.input).Inspect(); got != tt.expected {
+			t.Errorf("wrong Inspect for %s. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{"1.5 / 0", "division by zero"},
+		{"1 / 0.0", "division by zero"},
+		{`1.5 + "a"`, "type mismatch: FLOAT + STRING"},
+		{"[1, 2][1.0]", "index operator not supported: ARRAY[FLOAT]"},
+	}
+	for _, tt := range errors {
+		errObj, ok := testEval(t, tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("wrong error for %s. expected=%q, got=%v", tt.input, tt.expected, errObj)
+		}
+	}
+}
+
+func TestComparisonAndLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"2 >= 3", false},
+		{"2.5 >= 2", true},
+		{"1 <= 1.0", true},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 3 >= 3", true},
+		{"[] || false", true},
+		{"!(true && false)", true},
+	}
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestLogicalOperatorsShortCircuit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		// the right side would be an error if it was evaluated
+		{"false && undefined", false},
+		{"true || 1 / 0", true},
+		{"fn boom() { return 1 / 0; } false && boom()", false},
+		{"fn boom() { return 1 / 0; } true || boom()", true},
+	}
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(t, tt.input), tt.expected)
+	}
+
+	evaluated := testEval(t, "true && undefined")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "identifier not found: undefined" {
+		t.Errorf("right side not evaluated when needed. got=%v", evaluated)
+	}
+}
+
+func TestModuloPowerAndBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7 % -3", 1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"5 ** 0", 1},
+		{"0b1100 & 0b1010", 8},
+		{"0b1100 | 0b1010", 14},
+		{"0b1100 ^ 0b1010", 6},
+		{"~5", -6},
+		{"1 << 4", 16},
+		{"-16 >> 2", -4},
+		{"1 << 64", 0},
+		{"-1 >> 70", -1},
+		{"1 + 2 << 1", 6},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+	// comparisons bind looser than &, this is (6 & 3) == 2
+	testBooleanObject(t, testEval(t, "6 & 3 == 2"), true)
+
+	testFloatObject(t, testEval(t, "7.5 % 2"), 1.5)
+	testFloatObject(t, testEval(t, "2 ** 0.5 ** 2"), 1.189207115002721)
+	testFloatObject(t, testEval(t, "4.0 ** -1"), 0.25)
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{"1 % 0", "modulo by zero"},
+		{"1.5 % 0", "modulo by zero"},
+		{"2 ** -1", "negative exponent: -1"},
+		{"1 << -1", "negative shift count: -1"},
+		{"8 >> -2", "negative shift count: -2"},
+		{"1.5 & 1", "unknown operator: FLOAT & INTEGER"},
+		{"~true", "unknown operator: ~BOOLEAN"},
+		{"true | false", "unknown operator: BOOLEAN | BOOLEAN"},
+	}
+	for _, tt := range errors {
+		errObj, ok := testEval(t, tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("wrong error for %s. expected=%q, got=%v", tt.input, tt.expected, errObj)
+		}
+	}
+}
+
+func TestElseIfChains(t *testing.T) {
+	input := `fn sign(x) {
+	if (x < 0) { return "negative"; } else if (x == 0) { return "zero"; } else { return "positive"; }
+}
+sign(-5) + " " + sign(0) + " " + sign(3)`
+	evaluated := testEval(t, input)
+	str, ok := evaluated.(*object.String)
+	if !ok || str.Value != "negative zero positive" {
+		t.Errorf("wrong result. got=%v", evaluated)
+	}
+	testNullObject(t, testEval(t, "if (false) { 1 } else if (false) { 2 }"))
+}
+
+func TestIfExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = if (true) { 1 } else { 2 }; x", 1},
+		{"let x = if (1 > 2) { 1 } else { 2 }; x", 2},
+		{"let x = if (false) { 1 } else if (true) { 2 } else { 3 }; x", 2},
+		{"let x = if (false) { 1 }; x", nil},
+		{"let x = if (true) { let y = 2; }; x", nil},
+		{"let x = if (true) {}; x", nil},
+		{"let x = if (true) { let y = 2; y * 3 } else { 0 }; x", 6},
+		{"1 + if (false) { 10 } else { 20 } * 2", 41},
+		{"fn abs(n) { if (n < 0) { -n } else { n } } abs(-4) + abs(4)", 8},
+		{"let f = fn(b) { let v = if (b) { \"yes\" } else { \"no\" }; len(v) }; f(true) + f(false)", 5},
+		{"let f = fn(a) { let x = if (a) { return 5; } else { 2 }; x + 100 }; f(true) * 1000 + f(false)", 5102},
+		{"let f = fn(a) { [1, if (a) { return 7; } else { 2 }][1] * 2 }; f(true) + f(false)", 11},
+		{"let f = fn() { return if (true) { return 3; } else { 4 } + 10; }; f()", 3},
+		{"let f = fn(a) { a = a + if (a > 1) { return -1; } else { a }; a }; f(2) * 10 + f(1)", -8},
+		{"let x = if (true) { return 9; } else { 0 }; x + 1", 9},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 5) { i = i + 1; } i", 5},
+		{"let i = 0; while (false) { i = 1; } i", 0},
+		{"let s = 0; for x in [1, 2, 3] { s = s + x; } s", 6},
+		{"let s = 0; for x in [] { s = 1; } s", 0},
+		{"let n = 0; for c in \"héllo\" { n = n + 1; } n", 5},
+		{"let s = \"\"; for c in \"abc\" { s = c + s; } s", "cba"},
+		{"let s = \"\"; for k in {\"b\": 1, \"a\": 2} { s = s + k; } s", "ba"},
+		{"let i = 0; while (true) { i = i + 1; if (i == 3) { break; } } i", 3},
+		{"let i = 0; let s = 0; while (i < 5) { i = i + 1; let x = if (i == 3) { break; } else { i }; s = s + 1; } s", 2},
+		{"let r = 0; for k in [1, 2, 3] { r = r + if (k == 2) { continue; } else { k }; } r", 4},
+		{"let t = 0; for x in [1, 2] { for y in [1, 2] { t = t + [if (y == 2) { break; } else { y }][0]; } } t", 2},
+		{"let n = 0; for x in [1, 2, 3] { n = n + len(if (x == 2) { continue; } else { \"ab\" }); } n", 4},
+		{"let s = 0; for x in [1, 2, 3, 4] { if (x % 2 == 0) { continue; } s = s + x; } s", 4},
+		{"let i = 0; let s = 0; while (i < 10) { i = i + 1; if (i > 3) { continue; } s = s + i; } s", 6},
+		{"let n = 0; for a in [1, 2] { for b in [1, 2, 3] { if (b == 2) { break; } n = n + 1; } } n", 2},
+		{"fn find(xs, v) { for x in xs { if (x == v) { return true; } } false } find([1, 2], 2)", true},
+		{"fn find(xs, v) { for x in xs { if (x == v) { return true; } } false } find([1, 2], 3)", false},
+		{"fn f() { let i = 0; while (true) { i = i + 1; if (i == 4) { return i * 10; } } } f() + 1", 41},
+		{"let x = 0; for x in [7, 8] { } x", 0},
+		{"let fs = []; for x in [1, 2] { fs = push(fs, fn() { x }); } fs[0]() * 10 + fs[1]()", 12},
+		{"let i = 0; let n = 0; while (i < 3) { let d = i * 2; i = i + 1; n = n + d; } n", 6},
+		{"let i = 0; while (i < 3) { i = i + 1; }", nil},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("wrong result for %s. expected=%q, got=%v", tt.input, expected, evaluated)
+			}
+		default:
+			if evaluated != nil {
+				t.Errorf("a loop has no value. got=%v", evaluated)
+			}
+		}
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{"for x in 5 { x; }", "cannot iterate over INTEGER"},
+		{"while (y) { 1; }", "identifier not found: y"},
+		{"for x in [1, 2] { x + true; }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+	for _, tt := range errors {
+		errObj, ok := testEval(t, tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("wrong error for %s. expected=%q, got=%v", tt.input, tt.expected, errObj)
+		}
+	}
+}
+
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let newAdder = fn(x) { fn(y) { x + y } }; let addTwo = newAdder(2); addTwo(3)", 5},
+		{"let add = fn(a) { fn(b) { fn(c) { a + b + c } } }; add(1)(2)(3)", 6},
+		{`let counter = fn() { let n = 0; fn() { n = n + 1; n } };
+let c = counter(); c(); c(); c()`, 3},
+		{`let counter = fn() { let n = 0; fn() { n = n + 1; n } };
+let a = counter(); let b = counter(); a(); a(); b(); a() * 10 + b()`, 32},
+		{"let total = 0; let add = fn(x) { total = total + x; }; add(2); add(5); total", 7},
+		{"let x = 1; let f = fn() { let x = 2; x = x + 10; x }; f() * 100 + x", 1201},
+		{"let x = 1; let f = fn(x) { x = x + 1; x }; f(5) * 10 + x", 61},
+		{"let x = 1; let get = fn() { x }; x = 5; get()", 5},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5)", 120},
+		{"fn fib(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) } fib(15)", 610},
+		{`let outer = fn() {
+	let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };
+	let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };
+	if (even(10)) { 1 } else { 0 }
+}; outer()`, 1},
+		{"let compose = fn(f, g) { fn(x) { f(g(x)) } }; let inc = fn(x) { x + 1 }; compose(inc, inc)(1)", 3},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn() { y = 1; }; f()", "assignment to undeclared identifier: y"},
+		{"let f = fn() { let y = 1; }; f(); y = 2;", "assignment to undeclared identifier: y"},
+		{"let x = 1; x = z;", "identifier not found: z"},
+		{"if (true) { let y = 1; } y", "identifier not found: y"},
+		{"for x in [1] { } x", "identifier not found: x"},
+	}
+	for _, tt := range errors {
+		errObj, ok := testEval(t, tt.input).(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("wrong error for %s. expected=%q, got=%v", tt.input, tt.expected, errObj)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(ws.Condition, env)
+		if isAbrupt(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+		if result, done := evalLoopBody(ws.Body, object.NewEnclosedEnvironment(env)); done {
+			return result
+		}
+	}
+}
+
+// for x in iterable runs the body once for each element of an array, each
+// character of a string or each key of a hash in insertion order
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(fs.Iterable, env)
+	if isAbrupt(iterable) {
+		return iterable
+	}
+	elements, ok := iterationElements(iterable)
+	if !ok {
+		return object.NewError("cannot iterate over %s", iterable.Type())
+	}
+	for _, element := range elements {
+		// each iteration has its own variable so closures made in the body keep theirs
+		bodyEnv := object.NewEnclosedEnvironment(env)
+		bodyEnv.Set(fs.Variable.Value, element)
+		if result, done := evalLoopBody(fs.Body, bodyEnv); done {
+			return result
+		}
+	}
+	return nil
+}
+
+func iterationElements(iterable object.Object) ([]object.Object, bool) {
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		return iterable.Elements, true
+	case *object.String:
+		elements := []object.Object{}
+		for _, r := range iterable.Value {
+			elements = append(elements, &object.String{Value: string(r)})
+		}
+		return elements, true
+	case *object.Hash:
+		elements := make([]object.Object, 0, len(iterable.Keys))
+		for _, key := range iterable.Keys {
+			elements = append(elements, iterable.Pairs[key].Key)
+		}
+		return elements, true
+	}
+	return nil, false
+}
+
+// runs one iteration, done is set when the loop has to stop: on break, or on a
+// return or an error which is handed to the blocks around the loop
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	result := Eval(body, env)
+	if result == nil {
+		return nil, false
+	}
+	switch result.Type() {
+	case object.ObjectTypeBreak:
+		return nil, true
+	case object.ObjectTypeReturnValue, object.ObjectTypeError:
+		return result, true
+	}
+	return nil, false
+}
@@ -0,0 +1,27 @@
+package evaluator
+
+import "github.com/eyanshu1997/yacgo/object"
+
+// the semantics of the operators for other backends like the vm, so every way
+// of running a program gives the same results and the same errors
+
+func Prefix(operator string, right object.Object) object.Object {
+	return evalPrefixExpression(operator, right)
+}
+
+func Infix(operator string, left, right object.Object) object.Object {
+	return evalInfixExpression(operator, left, right)
+}
+
+func Index(left, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
+
+// the elements a for loop goes over, false if obj cannot be iterated
+func Iterate(obj object.Object) ([]object.Object, bool) {
+	return iterationElements(obj)
+}
@@ -0,0 +1,293 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalExclaimOperatorExpression(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalBitNotPrefixOperatorExpression(right)
+	default:
+		return object.NewError("unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+func evalExclaimOperatorExpression(right object.Object) object.Object {
+	return nativeBoolToBooleanObject(!isTruthy(right))
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return object.NewError("unknown operator: -%s", right.Type())
+	}
+}
+
+func evalBitNotPrefixOperatorExpression(right object.Object) object.Object {
+	integer, ok := right.(*object.Integer)
+	if !ok {
+		return object.NewError("unknown operator: ~%s", right.Type())
+	}
+	return &object.Integer{Value: ^integer.Value}
+}
+
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.ObjectTypeInteger && right.Type() == object.ObjectTypeInteger:
+		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		// an integer mixed with a float is converted to float
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.ObjectTypeString && right.Type() == object.ObjectTypeString:
+		return evalStringInfixExpression(operator, left, right)
+	case left.Type() != right.Type():
+		return object.NewError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	// booleans and null are singletons so pointer comparison is enough
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// && and || only evaluate the right side when the left one does not decide
+// the result, which is always a boolean
+func evalLogicalExpression(node *ast.InfixExpression, left object.Object, env *object.Environment) object.Object {
+	if node.Operator == "&&" && !isTruthy(left) {
+		return object.FALSE
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return object.TRUE
+	}
+	right := Eval(node.Right, env)
+	if isAbrupt(right) {
+		return right
+	}
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+	switch operator {
+	case "+":
+		return &object.Integer{Value: leftVal + rightVal}
+	case "-":
+		return &object.Integer{Value: leftVal - rightVal}
+	case "*":
+		return &object.Integer{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return object.NewError("division by zero")
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		// the result has the sign of the left side, a == a / b * b + a % b
+		if rightVal == 0 {
+			return object.NewError("modulo by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "**":
+		if rightVal < 0 {
+			return object.NewError("negative exponent: %d", rightVal)
+		}
+		return &object.Integer{Value: integerPower(leftVal, rightVal)}
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case "<<", ">>":
+		return evalShift(operator, leftVal, rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return object.NewError("division by zero")
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return object.NewError("modulo by zero")
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// overflows wrap around like + and * do
+func integerPower(base, exponent int64) int64 {
+	result := int64(1)
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exponent >>= 1
+	}
+	return result
+}
+
+// a negative count is an error, shifting by 64 or more gives 0, or -1 when a
+// negative number is shifted right, as >> keeps the sign
+func evalShift(operator string, value, count int64) object.Object {
+	if count < 0 {
+		return object.NewError("negative shift count: %d", count)
+	}
+	if operator == "<<" {
+		return &object.Integer{Value: value << uint64(count)}
+	}
+	return &object.Integer{Value: value >> uint64(count)}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.ObjectTypeInteger || obj.Type() == object.ObjectTypeFloat
+}
+
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+// + concatenates, strings compare by value
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ObjectTypeArray && index.Type() == object.ObjectTypeInteger:
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.ObjectTypeHash:
+		return evalHashIndexExpression(left, index)
+	default:
+		return object.NewError("index operator not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
+
+// a negative index counts from the end, arr[-1] is the last element.
+// anything outside the array is an error
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	elements := array.(*object.Array).Elements
+	idx := index.(*object.Integer).Value
+	length := int64(len(elements))
+	if idx < 0 {
+		idx += length
+	}
+	if idx < 0 || idx >= length {
+		return object.NewError("index out of range: %d with length %d", index.(*object.Integer).Value, length)
+	}
+	return elements[idx]
+}
+
+// a missing key is null
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return object.NewError("unusable as hash key: %s", index.Type())
+	}
+	value, ok := hash.(*object.Hash).Get(key)
+	if !ok {
+		return object.NULL
+	}
+	return value
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return object.TRUE
+	}
+	return object.FALSE
+}
+
+// only null and false are falsy
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case object.NULL, object.FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+// errors, returns, breaks and continues stop the expression they come out of
+// and everything around it up to the function or the loop, they can come out
+// of an if expression
+func isAbrupt(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+	switch obj.Type() {
+	case object.ObjectTypeError, object.ObjectTypeReturnValue, object.ObjectTypeBreak, object.ObjectTypeContinue:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/eyanshu1997/yacgo/common/diff"
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/parser"
+	"github.com/eyanshu1997/yacgo/printer"
+)
+
+// runFmt formats the given files, or stdin when there are none, and returns the exit code
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result to the source file instead of stdout")
+	showDiff := flags.Bool("d", false, "print a diff instead of the formatted source")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: yacgo fmt [-w] [-d] [files...]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintf(os.Stderr, "fmt: -w needs a file\n")
+			return 2
+		}
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fmt: %s\n", err)
+			return 1
+		}
+		return formatSource("<stdin>", string(source), false, *showDiff)
+	}
+	code := 0
+	for _, name := range flags.Args() {
+		source, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fmt: %s\n", err)
+			code = 1
+			continue
+		}
+		if c := formatSource(name, string(source), *write, *showDiff); c != 0 {
+			code = c
+		}
+	}
+	return code
+}
+
+func formatSource(name, source string, write, showDiff bool) int {
+	l := lexer.NewLexer(source, lexer.WithFilename(name), lexer.WithComments())
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		// never rewrite a file the parser did not fully understand
+		io.WriteString(os.Stderr, diagnostic.RenderAll(p.Errors(), source))
+		return 1
+	}
+	formatted := printer.Format(program)
+	if showDiff {
+		io.WriteString(os.Stdout, diff.Unified(name+".orig", name, source, formatted))
+	}
+	if write {
+		if formatted == source {
+			return 0
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fmt: %s\n", err)
+			return 1
+		}
+		if err := os.WriteFile(name, []byte(formatted), info.Mode().Perm()); err != nil {
+			fmt.Fprintf(os.Stderr, "fmt: %s\n", err)
+			return 1
+		}
+		return 0
+	}
+	if !showDiff {
+		io.WriteString(os.Stdout, formatted)
+	}
+	return 0
+}
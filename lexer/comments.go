@@ -0,0 +1,37 @@
+package lexer
+
+import (
+	"github.com/eyanshu1997/yacgo/diagnostic"
+)
+
+func (l *Lexer) atComment() bool {
+	return l.ch == '/' && (l.peekNextChar() == '/' || l.peekNextChar() == '*')
+}
+
+// reads a // or /* */ comment starting at ch, returns it with its markers.
+// a line comment stops before the newline, block comments do not nest
+func (l *Lexer) readComment() string {
+	start := l.currentPosition()
+	position := l.position
+	l.readNextChar()
+	if l.ch == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readNextChar()
+		}
+		return l.slice(position, l.position)
+	}
+	l.readNextChar()
+	for {
+		if l.ch == 0 {
+			l.addError(diagnostic.CodeUnterminatedComment, start, l.currentPosition(),
+				"comment not terminated")
+			return l.slice(position, l.position)
+		}
+		if l.ch == '*' && l.peekNextChar() == '/' {
+			l.readNextChar()
+			l.readNextChar()
+			return l.slice(position, l.position)
+		}
+		l.readNextChar()
+	}
+}
@@ -1,83 +1,164 @@
 package lexer
 
 import (
+	"io"
+	"unicode/utf8"
+
 	"github.com/eyanshu1997/yacgo/common/log"
 	"github.com/eyanshu1997/yacgo/common/utils"
+	"github.com/eyanshu1997/yacgo/diagnostic"
 	"github.com/eyanshu1997/yacgo/tokens"
 )
 
 type Lexer struct {
-	input        string
+	buf          []byte    // the input from offset base on
+	base         int       // offset of buf[0]
+	reader       io.Reader // where the rest of the input comes from, nil once it is all in buf
+	readErr      error     // the error that stopped reader, not reported yet
+	mark         int       // offset of the current token, nothing before it is needed anymore
+	file         string
 	position     int
 	readPosition int
-	ch           byte
+	ch           rune // the current character, utf8.RuneError for a byte that is not valid UTF-8
+	line         int  // line of ch
+	column       int  // column of ch
+	errors       []*diagnostic.Diagnostic
+	comments     bool // return comments as tokens instead of skipping them
+}
+
+type Option func(*Lexer)
+
+// file name reported in the positions of every token
+func WithFilename(name string) Option {
+	return func(l *Lexer) {
+		l.file = name
+	}
 }
 
-func NewLexer(input string) *Lexer {
-	l := &Lexer{input: input}
+// comments are returned as TokenTypeComment tokens, by default they are skipped like whitespace
+func WithComments() Option {
+	return func(l *Lexer) {
+		l.comments = true
+	}
+}
+
+func NewLexer(input string, opts ...Option) *Lexer {
+	l := &Lexer{buf: []byte(input), line: 1}
+	for _, opt := range opts {
+		opt(l)
+	}
 	l.readNextChar()
 	return l
 }
 
+// problems found while reading the tokens, like unterminated strings
+func (l *Lexer) Errors() []*diagnostic.Diagnostic {
+	return l.errors
+}
+
+func (l *Lexer) addError(code diagnostic.Code, start, end tokens.Position, format string, args ...interface{}) {
+	d := diagnostic.NewError(code, start, end, format, args...)
+	log.Println(d.Error())
+	l.errors = append(l.errors, d)
+}
+
+func (l *Lexer) currentPosition() tokens.Position {
+	return tokens.Position{File: l.file, Line: l.line, Column: l.column, Offset: l.position}
+}
+
+// position just after ch, the end of a span that includes ch
+func (l *Lexer) nextPosition() tokens.Position {
+	pos := l.currentPosition()
+	pos.Column++
+	pos.Offset = l.readPosition
+	return pos
+}
+
+// decodes the next rune, columns count runes while positions and offsets count bytes
 func (l *Lexer) readNextChar() {
-	log.Printf("readNextChar Called readPosition %d position %d len input %d", l.readPosition, l.position, len(l.input))
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
-	} else {
-		l.ch = l.input[l.readPosition]
+	log.Printf("readNextChar Called readPosition %d position %d", l.readPosition, l.position)
+	if l.ch == 0 && l.readPosition > 0 && !l.has(l.position) {
+		// already at EOF
+		return
 	}
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
 	l.position = l.readPosition
-	l.readPosition = l.readPosition + 1
+	ch, size := l.runeAt(l.readPosition)
+	if size == 0 {
+		l.ch = 0
+		l.readPosition++
+		l.reportReadError()
+		return
+	}
+	l.ch = ch
+	l.readPosition += size
+	if l.invalidChar() {
+		l.addError(diagnostic.CodeInvalidUTF8, l.currentPosition(), l.nextPosition(),
+			"invalid UTF-8 byte %#x", l.byteAt(l.position))
+	}
+}
+
+// ch comes from a byte that is not valid UTF-8, it was already reported by readNextChar
+func (l *Lexer) invalidChar() bool {
+	return l.ch == utf8.RuneError && l.readPosition-l.position == 1
 }
 
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for utils.IsLetter(l.ch) {
+	for utils.IsIdentifierChar(l.ch) {
 		l.readNextChar()
 	}
-	return l.input[position:l.position]
+	return l.slice(position, l.position)
 }
 
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		l.mark = l.position
 		l.readNextChar()
 	}
+	l.mark = l.position
 }
 
-func (l *Lexer) readNumber() string {
-	position := l.position
-	for utils.IsDigit(l.ch) {
-		l.readNextChar()
-	}
-	return l.input[position:l.position]
+func (l *Lexer) peekNextChar() rune {
+	ch, _ := l.runeAt(l.readPosition)
+	return ch
 }
 
-func (l *Lexer) peekNextChar() byte {
-	if l.readPosition >= len(l.input) {
-		return 0
-	} else {
-		return l.input[l.readPosition]
+// reads an operator like == or &&, leaves ch at its last character. nil if ch
+// and the next character are not one
+func (l *Lexer) getMultiToken() *tokens.Token {
+	literal := string(l.ch) + string(l.peekNextChar())
+	tokenType, ok := tokens.LookupMultiToken(literal)
+	if !ok {
+		return nil
 	}
-
+	l.readNextChar()
+	return &tokens.Token{Type: tokenType, Literal: literal}
 }
 
-func (l *Lexer) getMultiToken() *tokens.Token {
-	if l.ch == '=' && l.peekNextChar() == '=' {
-		ch := l.ch
-		l.readNextChar()
-		return &tokens.Token{Type: tokens.TokenTypeEQ, Literal: string(ch) + string(l.ch)}
-	}
-	if l.ch == '!' && l.peekNextChar() == '=' {
-		ch := l.ch
-		l.readNextChar()
-		return &tokens.Token{Type: tokens.TokenTypeNotEQ, Literal: string(ch) + string(l.ch)}
+func (l *Lexer) ReadNextToken() *tokens.Token {
+	l.skipWhitespace()
+	for l.atComment() {
+		start := l.currentPosition()
+		comment := l.readComment()
+		if l.comments {
+			return &tokens.Token{Type: tokens.TokenTypeComment, Literal: comment, Start: start, End: l.currentPosition()}
+		}
+		l.skipWhitespace()
 	}
-	return nil
+	start := l.currentPosition()
+	tok := l.readToken()
+	tok.Start = start
+	tok.End = l.currentPosition()
+	return tok
 }
 
-func (l *Lexer) ReadNextToken() *tokens.Token {
+func (l *Lexer) readToken() *tokens.Token {
 	var tok = &tokens.Token{}
-	l.skipWhitespace()
 	if tokens.CanHaveNextToken(l.ch) {
 		tok = l.getMultiToken()
 		if tok != nil {
@@ -91,6 +172,8 @@ func (l *Lexer) ReadNextToken() *tokens.Token {
 		tok = tokens.NewToken(tokens.TokenTypeComma, l.ch)
 	case ';':
 		tok = tokens.NewToken(tokens.TokenTypeSemiColon, l.ch)
+	case ':':
+		tok = tokens.NewToken(tokens.TokenTypeColon, l.ch)
 	case '(':
 		tok = tokens.NewToken(tokens.TokenTypeLParen, l.ch)
 	case ')':
@@ -99,6 +182,14 @@ func (l *Lexer) ReadNextToken() *tokens.Token {
 		tok = tokens.NewToken(tokens.TokenTypeLBrace, l.ch)
 	case '}':
 		tok = tokens.NewToken(tokens.TokenTypeRBrace, l.ch)
+	case '[':
+		tok = tokens.NewToken(tokens.TokenTypeLBracket, l.ch)
+	case ']':
+		tok = tokens.NewToken(tokens.TokenTypeRBracket, l.ch)
+	case '"':
+		tok.Type = tokens.TokenTypeString
+		tok.Literal = l.readString()
+		return tok
 
 	case '=':
 		tok = tokens.NewToken(tokens.TokenTypeAssign, l.ch)
@@ -117,6 +208,16 @@ func (l *Lexer) ReadNextToken() *tokens.Token {
 		tok = tokens.NewToken(tokens.TokenTypeAstrisk, l.ch)
 	case '/':
 		tok = tokens.NewToken(tokens.TokenTypeDivide, l.ch)
+	case '%':
+		tok = tokens.NewToken(tokens.TokenTypeModulo, l.ch)
+	case '&':
+		tok = tokens.NewToken(tokens.TokenTypeBitAnd, l.ch)
+	case '|':
+		tok = tokens.NewToken(tokens.TokenTypeBitOr, l.ch)
+	case '^':
+		tok = tokens.NewToken(tokens.TokenTypeBitXor, l.ch)
+	case '~':
+		tok = tokens.NewToken(tokens.TokenTypeBitNot, l.ch)
 
 	case 0:
 		tok.Type = tokens.TokenTypeEOF
@@ -126,10 +227,13 @@ func (l *Lexer) ReadNextToken() *tokens.Token {
 			tok.Type = tokens.CheckIfKeywordType(tok.Literal)
 			return tok
 		} else if utils.IsDigit(l.ch) {
-			tok.Type = tokens.TokenTypeInt
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
 			return tok
 		} else {
+			if !l.invalidChar() {
+				l.addError(diagnostic.CodeIllegalCharacter, l.currentPosition(), l.nextPosition(),
+					"illegal character %q", l.ch)
+			}
 			tok = tokens.NewToken(tokens.TokenTypeIllegal, l.ch)
 		}
 	}
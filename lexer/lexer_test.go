@@ -1,8 +1,11 @@
 package lexer
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/eyanshu1997/yacgo/diagnostic"
 	"github.com/eyanshu1997/yacgo/tokens"
 )
 
@@ -36,6 +39,41 @@ func TestNextToken(t *testing.T) {
 	}
 }
 
+func TestBracketTokens(t *testing.T) {
+	input := `[1, 2][0] {"a": 1}`
+	tests := []struct {
+		expectedType    tokens.TokenType
+		expectedLiteral string
+	}{
+		{tokens.TokenTypeLBracket, "["},
+		{tokens.TokenTypeInt, "1"},
+		{tokens.TokenTypeComma, ","},
+		{tokens.TokenTypeInt, "2"},
+		{tokens.TokenTypeRBracket, "]"},
+		{tokens.TokenTypeLBracket, "["},
+		{tokens.TokenTypeInt, "0"},
+		{tokens.TokenTypeRBracket, "]"},
+		{tokens.TokenTypeLBrace, "{"},
+		{tokens.TokenTypeString, "a"},
+		{tokens.TokenTypeColon, ":"},
+		{tokens.TokenTypeInt, "1"},
+		{tokens.TokenTypeRBrace, "}"},
+		{tokens.TokenTypeEOF, ""},
+	}
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.ReadNextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
 func TestLotsOfTokens(t *testing.T) {
 	input := `let five = 5;
 	let ten = 10;
@@ -122,3 +160,348 @@ func TestMultiToken(t *testing.T) {
 		}
 	}
 }
+
+func TestComparisonTokens(t *testing.T) {
+	input := `<=>=&&||<>!=`
+	tests := []struct {
+		expectedType    tokens.TokenType
+		expectedLiteral string
+	}{
+		{tokens.TokenTypeLTE, "<="},
+		{tokens.TokenTypeGTE, ">="},
+		{tokens.TokenTypeAnd, "&&"},
+		{tokens.TokenTypeOr, "||"},
+		{tokens.TokenTypeLT, "<"},
+		{tokens.TokenTypeGT, ">"},
+		{tokens.TokenTypeNotEQ, "!="},
+		{tokens.TokenTypeEOF, ""},
+	}
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.ReadNextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestArithmeticTokens(t *testing.T) {
+	input := `**<<>>&|^~%*&&`
+	tests := []struct {
+		expectedType    tokens.TokenType
+		expectedLiteral string
+	}{
+		{tokens.TokenTypePower, "**"},
+		{tokens.TokenTypeShl, "<<"},
+		{tokens.TokenTypeShr, ">>"},
+		{tokens.TokenTypeBitAnd, "&"},
+		{tokens.TokenTypeBitOr, "|"},
+		{tokens.TokenTypeBitXor, "^"},
+		{tokens.TokenTypeBitNot, "~"},
+		{tokens.TokenTypeModulo, "%"},
+		{tokens.TokenTypeAstrisk, "*"},
+		{tokens.TokenTypeAnd, "&&"},
+		{tokens.TokenTypeEOF, ""},
+	}
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.ReadNextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 10;\n  x == 5;"
+	tests := []struct {
+		expectedLiteral string
+		expectedStart   tokens.Position
+		expectedEnd     tokens.Position
+	}{
+		{"let", tokens.Position{File: "a.yapl", Line: 1, Column: 1, Offset: 0}, tokens.Position{File: "a.yapl", Line: 1, Column: 4, Offset: 3}},
+		{"x", tokens.Position{File: "a.yapl", Line: 1, Column: 5, Offset: 4}, tokens.Position{File: "a.yapl", Line: 1, Column: 6, Offset: 5}},
+		{"=", tokens.Position{File: "a.yapl", Line: 1, Column: 7, Offset: 6}, tokens.Position{File: "a.yapl", Line: 1, Column: 8, Offset: 7}},
+		{"10", tokens.Position{File: "a.yapl", Line: 1, Column: 9, Offset: 8}, tokens.Position{File: "a.yapl", Line: 1, Column: 11, Offset: 10}},
+		{";", tokens.Position{File: "a.yapl", Line: 1, Column: 11, Offset: 10}, tokens.Position{File: "a.yapl", Line: 1, Column: 12, Offset: 11}},
+		{"x", tokens.Position{File: "a.yapl", Line: 2, Column: 3, Offset: 14}, tokens.Position{File: "a.yapl", Line: 2, Column: 4, Offset: 15}},
+		{"==", tokens.Position{File: "a.yapl", Line: 2, Column: 5, Offset: 16}, tokens.Position{File: "a.yapl", Line: 2, Column: 7, Offset: 18}},
+		{"5", tokens.Position{File: "a.yapl", Line: 2, Column: 8, Offset: 19}, tokens.Position{File: "a.yapl", Line: 2, Column: 9, Offset: 20}},
+		{";", tokens.Position{File: "a.yapl", Line: 2, Column: 9, Offset: 20}, tokens.Position{File: "a.yapl", Line: 2, Column: 10, Offset: 21}},
+		{"", tokens.Position{File: "a.yapl", Line: 2, Column: 10, Offset: 21}, tokens.Position{File: "a.yapl", Line: 2, Column: 10, Offset: 21}},
+	}
+	l := NewLexer(input, WithFilename("a.yapl"))
+	for i, tt := range tests {
+		tok := l.ReadNextToken()
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Start != tt.expectedStart {
+			t.Fatalf("tests[%d] - start wrong. expected=%+v, got=%+v",
+				i, tt.expectedStart, tok.Start)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Fatalf("tests[%d] - end wrong. expected=%+v, got=%+v",
+				i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	input := `"foobar" "foo bar" "a\nb\t\"c\"\\" "\u{48}\u{e9}\u{1F600}" ""`
+	tests := []struct {
+		expectedType    tokens.TokenType
+		expectedLiteral string
+	}{
+		{tokens.TokenTypeString, "foobar"},
+		{tokens.TokenTypeString, "foo bar"},
+		{tokens.TokenTypeString, "a\nb\t\"c\"\\"},
+		{tokens.TokenTypeString, "Hé😀"},
+		{tokens.TokenTypeString, ""},
+		{tokens.TokenTypeEOF, ""},
+	}
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.ReadNextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Fatalf("unexpected lexer errors %v", l.Errors())
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+		expectedCode  diagnostic.Code
+	}{
+		{`"abc`, "1:1: string literal not terminated", diagnostic.CodeUnterminatedString},
+		{"\"abc\nlet", "1:1: string literal not terminated", diagnostic.CodeUnterminatedString},
+		{`"a\qb"`, `1:3: unknown escape sequence \q`, diagnostic.CodeInvalidEscape},
+		{`"\u41"`, `1:2: \u must be followed by {hex digits}`, diagnostic.CodeInvalidEscape},
+		{`"\u{}"`, `1:2: \u{...} needs 1 to 6 hex digits and a closing }`, diagnostic.CodeInvalidEscape},
+		{`"\u{110000}"`, `1:2: \u{110000} is not a valid unicode code point`, diagnostic.CodeInvalidEscape},
+		{`let @`, `1:5: illegal character '@'`, diagnostic.CodeIllegalCharacter},
+	}
+	for i, tt := range tests {
+		l := NewLexer(tt.input)
+		for tok := l.ReadNextToken(); tok.Type != tokens.TokenTypeEOF; tok = l.ReadNextToken() {
+		}
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d %v", i, len(errors), errors)
+		}
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("tests[%d] - wrong error. expected=%q, got=%q", i, tt.expectedError, errors[0].Error())
+		}
+		if errors[0].Code != tt.expectedCode {
+			t.Errorf("tests[%d] - wrong code. expected=%s, got=%s", i, tt.expectedCode, errors[0].Code)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `let a = 1; // one
+/* two
+   lines */ a / 2 /**/
+// last`
+	expected := []struct {
+		expectedType    tokens.TokenType
+		expectedLiteral string
+		expectedStart   string
+	}{
+		{tokens.TokenTypeLet, "let", "1:1"},
+		{tokens.TokenTypeIdentifier, "a", "1:5"},
+		{tokens.TokenTypeAssign, "=", "1:7"},
+		{tokens.TokenTypeInt, "1", "1:9"},
+		{tokens.TokenTypeSemiColon, ";", "1:10"},
+		{tokens.TokenTypeComment, "// one", "1:12"},
+		{tokens.TokenTypeComment, "/* two\n   lines */", "2:1"},
+		{tokens.TokenTypeIdentifier, "a", "3:13"},
+		{tokens.TokenTypeDivide, "/", "3:15"},
+		{tokens.TokenTypeInt, "2", "3:17"},
+		{tokens.TokenTypeComment, "/**/", "3:19"},
+		{tokens.TokenTypeComment, "// last", "4:1"},
+		{tokens.TokenTypeEOF, "", "4:8"},
+	}
+
+	l := NewLexer(input, WithComments())
+	for i, tt := range expected {
+		tok := l.ReadNextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Start.String() != tt.expectedStart {
+			t.Errorf("tests[%d] - wrong start. expected=%s, got=%s", i, tt.expectedStart, tok.Start)
+		}
+	}
+
+	// without the option the comments are skipped
+	l = NewLexer(input)
+	var literals []string
+	for tok := l.ReadNextToken(); tok.Type != tokens.TokenTypeEOF; tok = l.ReadNextToken() {
+		literals = append(literals, tok.Literal)
+	}
+	if strings.Join(literals, " ") != "let a = 1 ; a / 2" {
+		t.Errorf("comments were not skipped. got=%q", literals)
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := NewLexer("let a /* never\nclosed")
+	for tok := l.ReadNextToken(); tok.Type != tokens.TokenTypeEOF; tok = l.ReadNextToken() {
+	}
+	errors := l.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got=%d %v", len(errors), errors)
+	}
+	if errors[0].Error() != "1:7: comment not terminated" || errors[0].Code != diagnostic.CodeUnterminatedComment {
+		t.Errorf("wrong error. got=%s %q", errors[0].Code, errors[0].Error())
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "let größe = \"😀 ok\";\nπ2 + größe"
+	tests := []struct {
+		expectedType    tokens.TokenType
+		expectedLiteral string
+		expectedStart   tokens.Position
+		expectedEnd     tokens.Position
+	}{
+		{tokens.TokenTypeLet, "let", tokens.Position{Line: 1, Column: 1, Offset: 0}, tokens.Position{Line: 1, Column: 4, Offset: 3}},
+		{tokens.TokenTypeIdentifier, "größe", tokens.Position{Line: 1, Column: 5, Offset: 4}, tokens.Position{Line: 1, Column: 10, Offset: 11}},
+		{tokens.TokenTypeAssign, "=", tokens.Position{Line: 1, Column: 11, Offset: 12}, tokens.Position{Line: 1, Column: 12, Offset: 13}},
+		{tokens.TokenTypeString, "😀 ok", tokens.Position{Line: 1, Column: 13, Offset: 14}, tokens.Position{Line: 1, Column: 19, Offset: 23}},
+		{tokens.TokenTypeSemiColon, ";", tokens.Position{Line: 1, Column: 19, Offset: 23}, tokens.Position{Line: 1, Column: 20, Offset: 24}},
+		{tokens.TokenTypeIdentifier, "π2", tokens.Position{Line: 2, Column: 1, Offset: 25}, tokens.Position{Line: 2, Column: 3, Offset: 28}},
+		{tokens.TokenTypePlus, "+", tokens.Position{Line: 2, Column: 4, Offset: 29}, tokens.Position{Line: 2, Column: 5, Offset: 30}},
+		{tokens.TokenTypeIdentifier, "größe", tokens.Position{Line: 2, Column: 6, Offset: 31}, tokens.Position{Line: 2, Column: 11, Offset: 38}},
+		{tokens.TokenTypeEOF, "", tokens.Position{Line: 2, Column: 11, Offset: 38}, tokens.Position{Line: 2, Column: 11, Offset: 38}},
+	}
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.ReadNextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Start != tt.expectedStart || tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - wrong span. expected=%+v-%+v, got=%+v-%+v", i, tt.expectedStart, tt.expectedEnd, tok.Start, tok.End)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors %v", l.Errors())
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+		expectedTypes []tokens.TokenType
+	}{
+		{"a \xff b", "1:3: invalid UTF-8 byte 0xff", []tokens.TokenType{tokens.TokenTypeIdentifier, tokens.TokenTypeIllegal, tokens.TokenTypeIdentifier}},
+		{"\"é\xc3\"", "1:3: invalid UTF-8 byte 0xc3", []tokens.TokenType{tokens.TokenTypeString}},
+		{"// \xfe\nx", "1:4: invalid UTF-8 byte 0xfe", []tokens.TokenType{tokens.TokenTypeIdentifier}},
+	}
+	for i, tt := range tests {
+		l := NewLexer(tt.input)
+		var types []tokens.TokenType
+		for tok := l.ReadNextToken(); tok.Type != tokens.TokenTypeEOF; tok = l.ReadNextToken() {
+			types = append(types, tok.Type)
+		}
+		if fmt.Sprint(types) != fmt.Sprint(tt.expectedTypes) {
+			t.Errorf("tests[%d] - wrong tokens. expected=%v, got=%v", i, tt.expectedTypes, types)
+		}
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d %v", i, len(errors), errors)
+		}
+		if errors[0].Error() != tt.expectedError || errors[0].Code != diagnostic.CodeInvalidUTF8 {
+			t.Errorf("tests[%d] - wrong error. expected=%q, got=%s %q", i, tt.expectedError, errors[0].Code, errors[0].Error())
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    tokens.TokenType
+		expectedLiteral string
+	}{
+		{"42", tokens.TokenTypeInt, "42"},
+		{"1_000_000", tokens.TokenTypeInt, "1_000_000"},
+		{"0xff", tokens.TokenTypeInt, "0xff"},
+		{"0XDead_Beef", tokens.TokenTypeInt, "0XDead_Beef"},
+		{"0o17", tokens.TokenTypeInt, "0o17"},
+		{"0b1010", tokens.TokenTypeInt, "0b1010"},
+		{"3.14", tokens.TokenTypeFloat, "3.14"},
+		{"1e-9", tokens.TokenTypeFloat, "1e-9"},
+		{"2.5E+3", tokens.TokenTypeFloat, "2.5E+3"},
+		{"1_0.0_1e1_0", tokens.TokenTypeFloat, "1_0.0_1e1_0"},
+	}
+	for i, tt := range tests {
+		l := NewLexer(tt.input)
+		tok := l.ReadNextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - wrong token. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if next := l.ReadNextToken(); next.Type != tokens.TokenTypeEOF {
+			t.Errorf("tests[%d] - expected the whole input in one token, got %q after it", i, next.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("tests[%d] - unexpected errors %v", i, l.Errors())
+		}
+	}
+
+	// a . not followed by a digit is not part of the number
+	l := NewLexer("1.x")
+	if tok := l.ReadNextToken(); tok.Type != tokens.TokenTypeInt || tok.Literal != "1" {
+		t.Errorf("wrong token for 1.x. got=%q %q", tok.Type, tok.Literal)
+	}
+}
+
+func TestNumberErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"0x", "1:1: hexadecimal literal has no digits"},
+		{"0b102", "1:5: invalid digit '2' in binary literal"},
+		{"0o78", "1:4: invalid digit '8' in octal literal"},
+		{"1__0", "1:2: '_' must separate successive digits"},
+		{"10_", "1:3: '_' must separate successive digits"},
+		{"0x_1", "1:3: '_' must separate successive digits"},
+		{"1e+", "1:1: exponent has no digits"},
+	}
+	for i, tt := range tests {
+		l := NewLexer(tt.input)
+		for tok := l.ReadNextToken(); tok.Type != tokens.TokenTypeEOF; tok = l.ReadNextToken() {
+		}
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d %v", i, len(errors), errors)
+		}
+		if errors[0].Error() != tt.expectedError || errors[0].Code != diagnostic.CodeInvalidNumber {
+			t.Errorf("tests[%d] - wrong error. expected=%q, got=%s %q", i, tt.expectedError, errors[0].Code, errors[0].Error())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package lexer
+
+import (
+	"github.com/eyanshu1997/yacgo/common/utils"
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+var basePrefixes = map[rune]struct {
+	base int
+	name string
+}{
+	'x': {16, "hexadecimal"}, 'X': {16, "hexadecimal"},
+	'o': {8, "octal"}, 'O': {8, "octal"},
+	'b': {2, "binary"}, 'B': {2, "binary"},
+}
+
+// reads an integer or float literal starting at ch. the literal is returned as
+// written, with its prefix and _ separators, the parser converts it.
+//
+//	42  1_000  0xff  0o17  0b1010  3.14  1e-9  2.5E+3
+func (l *Lexer) readNumber() (tokens.TokenType, string) {
+	start := l.currentPosition()
+	position := l.position
+	if prefix, ok := basePrefixes[l.peekNextChar()]; ok && l.ch == '0' {
+		l.readNextChar()
+		l.readNextChar()
+		if l.readDigits(prefix.base, prefix.name) == 0 {
+			l.addError(diagnostic.CodeInvalidNumber, start, l.currentPosition(),
+				"%s literal has no digits", prefix.name)
+		}
+		return tokens.TokenTypeInt, l.slice(position, l.position)
+	}
+	tokenType := tokens.TokenTypeInt
+	l.readDigits(10, "decimal")
+	if l.ch == '.' && utils.IsDigit(l.peekNextChar()) {
+		tokenType = tokens.TokenTypeFloat
+		l.readNextChar()
+		l.readDigits(10, "decimal")
+	}
+	if l.ch == 'e' || l.ch == 'E' {
+		tokenType = tokens.TokenTypeFloat
+		l.readNextChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readNextChar()
+		}
+		if l.readDigits(10, "decimal") == 0 {
+			l.addError(diagnostic.CodeInvalidNumber, start, l.currentPosition(), "exponent has no digits")
+		}
+	}
+	return tokenType, l.slice(position, l.position)
+}
+
+// reads digits and _ separators, returns how many digits it read. digits that
+// are too big for the base are read too so they can be reported
+func (l *Lexer) readDigits(base int, name string) int {
+	digits := 0
+	reported := false
+	report := func(format string, args ...interface{}) {
+		if !reported {
+			l.addError(diagnostic.CodeInvalidNumber, l.currentPosition(), l.nextPosition(), format, args...)
+			reported = true
+		}
+	}
+	for {
+		switch {
+		case l.ch == '_':
+			if digits == 0 || !isDigitOfAnyBase(l.peekNextChar(), base) {
+				report("'_' must separate successive digits")
+			}
+		case isDigitOfAnyBase(l.ch, base):
+			if digitValue(l.ch) >= base {
+				report("invalid digit %q in %s literal", l.ch, name)
+			}
+			digits++
+		default:
+			return digits
+		}
+		l.readNextChar()
+	}
+}
+
+// hex literals can use any hex digit, the others any decimal digit
+func isDigitOfAnyBase(ch rune, base int) bool {
+	if base == 16 {
+		return utils.IsHexDigit(ch)
+	}
+	return utils.IsDigit(ch)
+}
+
+func digitValue(ch rune) int {
+	switch {
+	case utils.IsDigit(ch):
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	default:
+		return int(ch-'A') + 10
+	}
+}
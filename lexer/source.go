@@ -0,0 +1,89 @@
+package lexer
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"github.com/eyanshu1997/yacgo/diagnostic"
+)
+
+// bytes asked from the reader at a time
+const readBufferSize = 64 * 1024
+
+// NewLexerFromReader lexes the source read from r. only a window of the input
+// is kept in memory, from the start of the current token to what has been read
+// ahead, so large files do not need to be loaded whole. a read error is
+// reported as a diagnostic and ends the input
+func NewLexerFromReader(r io.Reader, opts ...Option) *Lexer {
+	l := &Lexer{reader: r, line: 1}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.readNextChar()
+	return l
+}
+
+// the byte at offset i is in the buffer, reading more of the input if needed
+func (l *Lexer) has(i int) bool {
+	l.fill(i + 1)
+	return i < l.base+len(l.buf)
+}
+
+// reads until the buffer holds the input up to offset end or the input ends
+func (l *Lexer) fill(end int) {
+	for l.reader != nil && end > l.base+len(l.buf) {
+		l.compact()
+		if cap(l.buf)-len(l.buf) < readBufferSize {
+			buf := make([]byte, len(l.buf), len(l.buf)+readBufferSize)
+			copy(buf, l.buf)
+			l.buf = buf
+		}
+		n, err := l.reader.Read(l.buf[len(l.buf):cap(l.buf)])
+		l.buf = l.buf[:len(l.buf)+n]
+		if err == io.EOF {
+			l.reader = nil
+		} else if err != nil {
+			// reported by readNextChar once it gets to the end of what was read
+			l.readErr = err
+			l.reader = nil
+		}
+	}
+}
+
+// drops the bytes before the current token, no literal needs them anymore
+func (l *Lexer) compact() {
+	drop := l.mark - l.base
+	if drop <= 0 {
+		return
+	}
+	n := copy(l.buf, l.buf[drop:])
+	l.buf = l.buf[:n]
+	l.base = l.mark
+}
+
+// a read error ends the input where it happened
+func (l *Lexer) reportReadError() {
+	if l.readErr == nil {
+		return
+	}
+	l.addError(diagnostic.CodeReadError, l.currentPosition(), l.currentPosition(), "reading source: %s", l.readErr)
+	l.readErr = nil
+}
+
+// the rune starting at offset i and its size in bytes, 0 at the end of the input
+func (l *Lexer) runeAt(i int) (rune, int) {
+	if !l.has(i) {
+		return 0, 0
+	}
+	l.fill(i + utf8.UTFMax)
+	return utf8.DecodeRune(l.buf[i-l.base:])
+}
+
+func (l *Lexer) byteAt(i int) byte {
+	return l.buf[i-l.base]
+}
+
+// the input between offsets from and to, from must not be before the current token
+func (l *Lexer) slice(from, to int) string {
+	return string(l.buf[from-l.base : to-l.base])
+}
@@ -0,0 +1,100 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+func readAll(l *Lexer) []tokens.Token {
+	var toks []tokens.Token
+	for {
+		tok := l.ReadNextToken()
+		toks = append(toks, *tok)
+		if tok.Type == tokens.TokenTypeEOF {
+			return toks
+		}
+	}
+}
+
+func TestReaderLexerMatchesStringLexer(t *testing.T) {
+	input := `let größe = "😀\u{41}\n"; // comment
+/* block
+comment */ fn add(a, b) { return a + b; }
+let h = {"k": [1, 22, 333]}; h["k"][0] == 1 != false
+"bad \q" @ ` + "\xff π"
+	expected := readAll(NewLexer(input, WithComments()))
+	expectedErrors := NewLexer(input)
+	readAll(expectedErrors)
+
+	// one byte at a time splits every multi byte rune and token across reads
+	l := NewLexerFromReader(iotest.OneByteReader(strings.NewReader(input)), WithComments())
+	got := readAll(l)
+	if len(got) != len(expected) {
+		t.Fatalf("wrong number of tokens. expected=%d, got=%d", len(expected), len(got))
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("tokens[%d] - expected=%+v, got=%+v", i, expected[i], got[i])
+		}
+	}
+	if len(l.Errors()) != len(expectedErrors.Errors()) {
+		t.Fatalf("wrong errors. expected=%v, got=%v", expectedErrors.Errors(), l.Errors())
+	}
+	for i, d := range expectedErrors.Errors() {
+		if l.Errors()[i].Error() != d.Error() {
+			t.Errorf("errors[%d] - expected=%q, got=%q", i, d.Error(), l.Errors()[i].Error())
+		}
+	}
+}
+
+func TestReaderLexerKeepsBufferSmall(t *testing.T) {
+	const statements = 20000
+	input := strings.Repeat("let value = value + 12345; // keep going\n", statements)
+	l := NewLexerFromReader(strings.NewReader(input))
+	count := 0
+	for tok := l.ReadNextToken(); tok.Type != tokens.TokenTypeEOF; tok = l.ReadNextToken() {
+		count++
+	}
+	if count != statements*7 {
+		t.Errorf("wrong number of tokens. expected=%d, got=%d", statements*7, count)
+	}
+	if cap(l.buf) > 2*readBufferSize {
+		t.Errorf("buffer grew to %d bytes for a %d byte input", cap(l.buf), len(input))
+	}
+}
+
+func TestReaderLexerLongToken(t *testing.T) {
+	long := strings.Repeat("a", 3*readBufferSize)
+	l := NewLexerFromReader(strings.NewReader("x " + long + " y"))
+	for _, expected := range []string{"x", long, "y", ""} {
+		tok := l.ReadNextToken()
+		if tok.Literal != expected {
+			t.Fatalf("wrong literal. expected %d bytes, got %d", len(expected), len(tok.Literal))
+		}
+	}
+}
+
+func TestReaderLexerReadError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("let a"), iotest.ErrReader(errors.New("disk on fire")))
+	l := NewLexerFromReader(r, WithFilename("big.yapl"))
+	var literals []string
+	for tok := l.ReadNextToken(); tok.Type != tokens.TokenTypeEOF; tok = l.ReadNextToken() {
+		literals = append(literals, tok.Literal)
+	}
+	if strings.Join(literals, " ") != "let a" {
+		t.Errorf("wrong tokens before the error. got=%q", literals)
+	}
+	errors := l.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got=%d %v", len(errors), errors)
+	}
+	if errors[0].Code != diagnostic.CodeReadError || errors[0].Error() != "big.yapl:1:6: reading source: disk on fire" {
+		t.Errorf("wrong error. got=%s %q", errors[0].Code, errors[0].Error())
+	}
+}
@@ -0,0 +1,92 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eyanshu1997/yacgo/common/utils"
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+// max hex digits in a \u{...} escape
+const maxUnicodeEscapeDigits = 6
+
+var simpleEscapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'"':  '"',
+	'\\': '\\',
+}
+
+// reads a string literal starting at the opening quote and returns its decoded value.
+// a string must end on the line it starts
+func (l *Lexer) readString() string {
+	start := l.currentPosition()
+	var out strings.Builder
+	for {
+		l.readNextChar()
+		switch l.ch {
+		case '"':
+			l.readNextChar()
+			return out.String()
+		case 0, '\n':
+			l.addError(diagnostic.CodeUnterminatedString, start, l.currentPosition(),
+				"string literal not terminated")
+			return out.String()
+		case '\\':
+			l.readEscape(&out)
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// l.ch is the backslash, leaves l.ch at the last character of the escape.
+// the end of the line is never consumed so readString can report it
+func (l *Lexer) readEscape(out *strings.Builder) {
+	start := l.currentPosition()
+	next := l.peekNextChar()
+	if next == 0 || next == '\n' {
+		return
+	}
+	l.readNextChar()
+	if ch, ok := simpleEscapes[l.ch]; ok {
+		out.WriteRune(ch)
+		return
+	}
+	if l.ch == 'u' {
+		l.readUnicodeEscape(start, out)
+		return
+	}
+	l.addError(diagnostic.CodeInvalidEscape, start, l.nextPosition(), "unknown escape sequence \\%c", l.ch)
+}
+
+// reads the {hex} part of \u{hex}, l.ch is the u
+func (l *Lexer) readUnicodeEscape(start tokens.Position, out *strings.Builder) {
+	if l.peekNextChar() != '{' {
+		l.addError(diagnostic.CodeInvalidEscape, start, l.currentPosition(),
+			"\\u must be followed by {hex digits}")
+		return
+	}
+	l.readNextChar()
+	var digits strings.Builder
+	for utils.IsHexDigit(l.peekNextChar()) && digits.Len() < maxUnicodeEscapeDigits {
+		l.readNextChar()
+		digits.WriteRune(l.ch)
+	}
+	if l.peekNextChar() != '}' || digits.Len() == 0 {
+		l.addError(diagnostic.CodeInvalidEscape, start, l.currentPosition(),
+			"\\u{...} needs 1 to %d hex digits and a closing }", maxUnicodeEscapeDigits)
+		return
+	}
+	l.readNextChar()
+	value, _ := strconv.ParseUint(digits.String(), 16, 32)
+	r := rune(value)
+	if !utf8.ValidRune(r) {
+		l.addError(diagnostic.CodeInvalidEscape, start, l.nextPosition(), "\\u{%s} is not a valid unicode code point", digits.String())
+		return
+	}
+	out.WriteRune(r)
+}
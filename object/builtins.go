@@ -0,0 +1,62 @@
+package object
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// builtins are kept in a slice so their index is stable
+var Builtins = []*Builtin{
+	{
+		Name: "len",
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return NewError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			switch arg := args[0].(type) {
+			case *String:
+				return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *Array:
+				return &Integer{Value: int64(len(arg.Elements))}
+			case *Hash:
+				return &Integer{Value: int64(len(arg.Keys))}
+			default:
+				return NewError("argument to `len` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	{
+		// returns a new array, the argument is not modified
+		Name: "push",
+		Fn: func(args ...Object) Object {
+			if len(args) != 2 {
+				return NewError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			arr, ok := args[0].(*Array)
+			if !ok {
+				return NewError("argument to `push` must be ARRAY, got %s", args[0].Type())
+			}
+			elements := make([]Object, len(arr.Elements), len(arr.Elements)+1)
+			copy(elements, arr.Elements)
+			return &Array{Elements: append(elements, args[1])}
+		},
+	},
+	{
+		Name: "puts",
+		Fn: func(args ...Object) Object {
+			for _, arg := range args {
+				fmt.Println(arg.Inspect())
+			}
+			return NULL
+		},
+	},
+}
+
+func GetBuiltinByName(name string) *Builtin {
+	for _, b := range Builtins {
+		if b.Name == name {
+			return b
+		}
+	}
+	return nil
+}
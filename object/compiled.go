@@ -0,0 +1,59 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/eyanshu1997/yacgo/code"
+)
+
+const (
+	ObjectTypeCompiledFunction ObjectType = "COMPILED_FUNCTION"
+	ObjectTypeCell             ObjectType = "CELL"
+)
+
+// the bytecode of a function, a constant the vm turns into a Closure
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumLocals     int // parameters first, then the variables of the body and its blocks
+	NumParameters int
+	Name          string // empty for function literals
+	Source        string // what Inspect of its closures gives, like the evaluator's functions
+	Lines         code.LineTable
+}
+
+func (cf *CompiledFunction) Type() ObjectType { return ObjectTypeCompiledFunction }
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%s/%d]", cf.Name, cf.NumParameters)
+}
+
+// a function value of the vm, Free are the cells of the variables it uses
+// from the functions around it. it is a FUNCTION like the evaluator's ones
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []*Cell
+}
+
+func (c *Closure) Type() ObjectType { return ObjectTypeFunction }
+func (c *Closure) Inspect() string {
+	if c.Fn.Source != "" {
+		return c.Fn.Source
+	}
+	if c.Fn.Name != "" {
+		return fmt.Sprintf("fn %s/%d", c.Fn.Name, c.Fn.NumParameters)
+	}
+	return fmt.Sprintf("fn/%d", c.Fn.NumParameters)
+}
+
+// a variable shared between a function and the closures it made, so an
+// assignment through one of them is seen by all
+type Cell struct {
+	Value Object
+}
+
+func (c *Cell) Type() ObjectType { return ObjectTypeCell }
+func (c *Cell) Inspect() string {
+	if c.Value == nil {
+		return "cell()"
+	}
+	return "cell(" + c.Value.Inspect() + ")"
+}
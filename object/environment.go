@@ -0,0 +1,58 @@
+package object
+
+// holds the bindings created by let statements
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+	depth int // function calls from the program to this environment
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]Object)}
+}
+
+// used for blocks, lookups fall back to outer
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	env.depth = outer.depth
+	return env
+}
+
+// the environment of a call made from caller to a function defined in outer
+func NewCallEnvironment(outer, caller *Environment) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.depth = caller.depth + 1
+	return env
+}
+
+// how many function calls deep the environment is, 0 outside of any function
+func (e *Environment) Depth() int {
+	return e.depth
+}
+
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+// binds name in this environment, shadowing any binding of an outer one
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
+
+// updates the nearest binding of name, false if there is none. a closure
+// assigning to a variable of the function that made it changes that variable
+func (e *Environment) Assign(name string, val Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = val
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/eyanshu1997/yacgo/ast"
+)
+
+type ObjectType string
+
+const (
+	ObjectTypeInteger     ObjectType = "INTEGER"
+	ObjectTypeFloat       ObjectType = "FLOAT"
+	ObjectTypeBoolean     ObjectType = "BOOLEAN"
+	ObjectTypeString      ObjectType = "STRING"
+	ObjectTypeArray       ObjectType = "ARRAY"
+	ObjectTypeHash        ObjectType = "HASH"
+	ObjectTypeNull        ObjectType = "NULL"
+	ObjectTypeReturnValue ObjectType = "RETURN_VALUE"
+	ObjectTypeError       ObjectType = "ERROR"
+	ObjectTypeBuiltin     ObjectType = "BUILTIN"
+	ObjectTypeFunction    ObjectType = "FUNCTION"
+	ObjectTypeBreak       ObjectType = "BREAK"
+	ObjectTypeContinue    ObjectType = "CONTINUE"
+)
+
+// every value the runtime works with implements this
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Type() ObjectType { return ObjectTypeInteger }
+func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return ObjectTypeFloat }
+
+// always looks like a float, 3.0 is not printed as 3
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Type() ObjectType { return ObjectTypeBoolean }
+func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return ObjectTypeString }
+func (s *String) Inspect() string  { return s.Value }
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ObjectTypeArray }
+func (a *Array) Inspect() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, e := range a.Elements {
+		elements = append(elements, e.Inspect())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+type Null struct{}
+
+func (n *Null) Type() ObjectType { return ObjectTypeNull }
+func (n *Null) Inspect() string  { return "null" }
+
+// wraps the value of a return statement so it can travel up through blocks
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() ObjectType { return ObjectTypeReturnValue }
+func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
+
+// Break and Continue are never values, like ReturnValue they stop the blocks
+// around them until they reach the loop they are in
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return ObjectTypeBreak }
+func (b *Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return ObjectTypeContinue }
+func (c *Continue) Inspect() string  { return "continue" }
+
+type Error struct {
+	Message string
+}
+
+func (e *Error) Type() ObjectType { return ObjectTypeError }
+func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+
+func NewError(format string, a ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...)}
+}
+
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return ObjectTypeBuiltin }
+func (b *Builtin) Inspect() string  { return "builtin " + b.Name }
+
+// a user defined function, Env is the environment it was defined in
+type Function struct {
+	Name       string // empty for function literals
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (f *Function) Type() ObjectType { return ObjectTypeFunction }
+func (f *Function) Inspect() string {
+	return FunctionSource(f.Name, f.Parameters, f.Body)
+}
+
+// FunctionSource is what Inspect gives for a function, name is empty for a
+// function literal
+func FunctionSource(name string, parameters []*ast.Identifier, body *ast.BlockStatement) string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString("fn")
+	if name != "" {
+		out.WriteString(" " + name)
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+// there is only ever one true, false and null
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+	NULL  = &Null{}
+)
+
+// break and continue carry no state either
+var (
+	BREAK    = &Break{}
+	CONTINUE = &Continue{}
+)
@@ -0,0 +1,394 @@
+package objfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+
+	"github.com/eyanshu1997/yacgo/code"
+	"github.com/eyanshu1997/yacgo/compiler"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+// Version of the format this package writes, it only reads the same version
+const Version = 1
+
+var magic = []byte("YBC\x00")
+
+// magic, version and the length of the body
+const headerSize = 4 + 2 + 4
+
+const checksumSize = 4
+
+// tags of the constant pool entries
+const (
+	tagInteger byte = iota + 1
+	tagFloat
+	tagString
+	tagFunction
+)
+
+var (
+	ErrNotBytecode = errors.New("not a yacgo bytecode file")
+	ErrTruncated   = errors.New("truncated bytecode file")
+	ErrVersion     = errors.New("unsupported bytecode version")
+	ErrChecksum    = errors.New("bytecode checksum mismatch")
+	ErrMalformed   = errors.New("malformed bytecode file")
+)
+
+// Encode writes the bytecode of a program as an object file
+//
+//	header    "YBC\0", version uint16, length of the body uint32
+//	body      functions uint32, then each function
+//	            name string, source string, parameters uint16, locals uint16,
+//	            instructions uint32 + bytes, line table uint32 + (offset, line, column uint32)
+//	          constants uint32, then each a tag byte and
+//	            integer int64 | float float64 bits | string | function uint32 index in the table
+//	checksum  crc32 (IEEE) of the body
+//
+// the program is function 0 of the table, integers are big endian and strings
+// are a uint32 length followed by their bytes
+func Encode(bytecode *compiler.Bytecode) ([]byte, error) {
+	e := &encoder{}
+	functions := []*object.CompiledFunction{{
+		Instructions: bytecode.Instructions,
+		NumLocals:    bytecode.NumLocals,
+		Lines:        bytecode.Lines,
+	}}
+	for _, constant := range bytecode.Constants {
+		if fn, ok := constant.(*object.CompiledFunction); ok {
+			functions = append(functions, fn)
+		}
+	}
+	e.uint32(len(functions))
+	for _, fn := range functions {
+		e.function(fn)
+	}
+	e.uint32(len(bytecode.Constants))
+	next := 1
+	for i, constant := range bytecode.Constants {
+		switch constant := constant.(type) {
+		case *object.Integer:
+			e.byte(tagInteger)
+			e.uint64(uint64(constant.Value))
+		case *object.Float:
+			e.byte(tagFloat)
+			e.uint64(math.Float64bits(constant.Value))
+		case *object.String:
+			e.byte(tagString)
+			e.string(constant.Value)
+		case *object.CompiledFunction:
+			e.byte(tagFunction)
+			e.uint32(next)
+			next++
+		default:
+			return nil, fmt.Errorf("constant %d: cannot encode a %s", i, constant.Type())
+		}
+	}
+
+	body := e.buf.Bytes()
+	out := make([]byte, 0, headerSize+len(body)+checksumSize)
+	out = append(out, magic...)
+	out = binary.BigEndian.AppendUint16(out, Version)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(body)))
+	out = append(out, body...)
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(body))
+	return out, nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) byte(b byte) { e.buf.WriteByte(b) }
+
+func (e *encoder) uint16(n int) {
+	e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+}
+
+func (e *encoder) uint32(n int) {
+	e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+}
+
+func (e *encoder) uint64(n uint64) {
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(len(s))
+	e.buf.WriteString(s)
+}
+
+func (e *encoder) function(fn *object.CompiledFunction) {
+	e.string(fn.Name)
+	e.string(fn.Source)
+	e.uint16(fn.NumParameters)
+	e.uint16(fn.NumLocals)
+	e.uint32(len(fn.Instructions))
+	e.buf.Write(fn.Instructions)
+	e.uint32(len(fn.Lines))
+	for _, entry := range fn.Lines {
+		e.uint32(entry.Offset)
+		e.uint32(entry.Line)
+		e.uint32(entry.Column)
+	}
+}
+
+// Decode loads an object file written by Encode. a file that is cut short, of
+// another version, damaged or with instructions the vm could not run is
+// rejected with an error wrapping one of the Err values
+func Decode(data []byte) (*compiler.Bytecode, error) {
+	n := len(magic)
+	if len(data) < n {
+		n = len(data)
+	}
+	if !bytes.Equal(data[:n], magic[:n]) {
+		return nil, ErrNotBytecode
+	}
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("%w: the header needs %d bytes, got %d", ErrTruncated, headerSize, len(data))
+	}
+	if version := binary.BigEndian.Uint16(data[4:]); version != Version {
+		return nil, fmt.Errorf("%w %d, expected version %d", ErrVersion, version, Version)
+	}
+	size := headerSize + int(binary.BigEndian.Uint32(data[6:])) + checksumSize
+	if len(data) < size {
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrTruncated, size, len(data))
+	}
+	if len(data) > size {
+		return nil, fmt.Errorf("%w: %d bytes after the end of the file", ErrMalformed, len(data)-size)
+	}
+	body := data[headerSize : size-checksumSize]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[size-checksumSize:]) {
+		return nil, ErrChecksum
+	}
+
+	d := &decoder{data: body}
+	count := d.uint32()
+	var functions []*object.CompiledFunction
+	for i := 0; i < count && d.err == nil; i++ {
+		functions = append(functions, d.function())
+	}
+	if d.err == nil && len(functions) == 0 {
+		d.fail("no program in the function table")
+	}
+	count = d.uint32()
+	var constants []object.Object
+	used := make(map[int]bool)
+	for i := 0; i < count && d.err == nil; i++ {
+		switch tag := d.byte(); tag {
+		case tagInteger:
+			constants = append(constants, &object.Integer{Value: int64(d.uint64())})
+		case tagFloat:
+			constants = append(constants, &object.Float{Value: math.Float64frombits(d.uint64())})
+		case tagString:
+			constants = append(constants, &object.String{Value: d.string()})
+		case tagFunction:
+			index := d.uint32()
+			if index == 0 || index >= len(functions) || used[index] {
+				d.fail("constant %d refers to function %d", i, index)
+				continue
+			}
+			used[index] = true
+			constants = append(constants, functions[index])
+		default:
+			d.fail("constant %d has the unknown tag %d", i, tag)
+		}
+	}
+	if d.err == nil && d.offset != len(d.data) {
+		d.fail("%d bytes after the constant pool", len(d.data)-d.offset)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	free := make(map[*object.CompiledFunction]int)
+	for i, fn := range functions {
+		n, err := check(fn, constants)
+		if err != nil {
+			return nil, fmt.Errorf("%w: function %d: %s", ErrMalformed, i, err)
+		}
+		free[fn] = n
+	}
+	if free[functions[0]] > 0 {
+		return nil, fmt.Errorf("%w: the program uses free variables", ErrMalformed)
+	}
+	// a closure has to get all the free variables its function uses
+	for i, fn := range functions {
+		if err := checkClosures(fn, constants, free); err != nil {
+			return nil, fmt.Errorf("%w: function %d: %s", ErrMalformed, i, err)
+		}
+	}
+	for i, fn := range functions {
+		if err := checkStack(fn, i == 0); err != nil {
+			return nil, fmt.Errorf("%w: function %d: %s", ErrMalformed, i, err)
+		}
+	}
+	main := functions[0]
+	return &compiler.Bytecode{Instructions: main.Instructions, Constants: constants, NumLocals: main.NumLocals, Lines: main.Lines}, nil
+}
+
+// reads the body, the first error sticks and every read after it gives zeros
+type decoder struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (d *decoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: %s", ErrMalformed, fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.data)-d.offset {
+		d.fail("the body ends in the middle of an entry")
+		return nil
+	}
+	b := d.data[d.offset : d.offset+n]
+	d.offset += n
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() int {
+	if b := d.bytes(2); b != nil {
+		return int(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) uint32() int {
+	if b := d.bytes(4); b != nil {
+		return int(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(d.uint32()))
+}
+
+func (d *decoder) function() *object.CompiledFunction {
+	fn := &object.CompiledFunction{Name: d.string(), Source: d.string()}
+	fn.NumParameters = d.uint16()
+	fn.NumLocals = d.uint16()
+	fn.Instructions = append(code.Instructions{}, d.bytes(d.uint32())...)
+	count := d.uint32()
+	for i := 0; i < count && d.err == nil; i++ {
+		fn.Lines = append(fn.Lines, code.LineEntry{Offset: d.uint32(), Line: d.uint32(), Column: d.uint32()})
+	}
+	return fn
+}
+
+// makes sure the vm can run the instructions of fn: every opcode is known and
+// complete, every operand that indexes something is in range and jumps land
+// on an instruction. it returns how many free variables fn uses, checkStack
+// goes over what the instructions do to the stack
+func check(fn *object.CompiledFunction, constants []object.Object) (int, error) {
+	if fn.NumParameters > fn.NumLocals {
+		return 0, fmt.Errorf("%d parameters but %d locals", fn.NumParameters, fn.NumLocals)
+	}
+	free := 0
+	starts := map[int]bool{len(fn.Instructions): true}
+	var jumps []int
+	err := instructions(fn.Instructions, func(offset int, op code.Opcode, operands []int) error {
+		starts[offset] = true
+		if op == code.OpGetGlobal || op == code.OpGetCell || op == code.OpGetFree {
+			if operands[1] >= len(constants) {
+				return fmt.Errorf("offset %d: no constant %d", offset, operands[1])
+			}
+			if _, ok := constants[operands[1]].(*object.String); !ok {
+				return fmt.Errorf("offset %d: constant %d is not a name", offset, operands[1])
+			}
+		}
+		switch op {
+		case code.OpConstant, code.OpClosure:
+			if operands[0] >= len(constants) {
+				return fmt.Errorf("offset %d: no constant %d", offset, operands[0])
+			}
+			if _, ok := constants[operands[0]].(*object.CompiledFunction); op == code.OpClosure && !ok {
+				return fmt.Errorf("offset %d: constant %d is not a function", offset, operands[0])
+			}
+		case code.OpGetBuiltin:
+			if operands[0] >= len(object.Builtins) {
+				return fmt.Errorf("offset %d: no builtin %d", offset, operands[0])
+			}
+		case code.OpGetLocal, code.OpSetLocal, code.OpNewCell, code.OpGetCell, code.OpSetCell, code.OpLoadCell:
+			if operands[0] >= fn.NumLocals {
+				return fmt.Errorf("offset %d: no local %d", offset, operands[0])
+			}
+		case code.OpGetFree, code.OpSetFree, code.OpLoadFree:
+			if operands[0] >= free {
+				free = operands[0] + 1
+			}
+		case code.OpJump, code.OpJumpNotTruthy, code.OpIterNext:
+			jumps = append(jumps, offset)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, offset := range jumps {
+		if target := int(code.ReadUint16(fn.Instructions[offset+1:])); !starts[target] {
+			return 0, fmt.Errorf("offset %d: jump to %d is not to an instruction", offset, target)
+		}
+	}
+	return free, nil
+}
+
+func checkClosures(fn *object.CompiledFunction, constants []object.Object, free map[*object.CompiledFunction]int) error {
+	return instructions(fn.Instructions, func(offset int, op code.Opcode, operands []int) error {
+		if op != code.OpClosure {
+			return nil
+		}
+		if needed := free[constants[operands[0]].(*object.CompiledFunction)]; operands[1] < needed {
+			return fmt.Errorf("offset %d: closure gets %d free variables, its function uses %d", offset, operands[1], needed)
+		}
+		return nil
+	})
+}
+
+// calls visit for every instruction of ins, stopping at the first error
+func instructions(ins code.Instructions, visit func(offset int, op code.Opcode, operands []int) error) error {
+	for i := 0; i < len(ins); {
+		op := code.Opcode(ins[i])
+		def, err := code.Lookup(op)
+		if err != nil {
+			return fmt.Errorf("offset %d: %s", i, err)
+		}
+		width := 0
+		for _, w := range def.OperandWidths {
+			width += w
+		}
+		if i+1+width > len(ins) {
+			return fmt.Errorf("offset %d: %s is cut short", i, def.Name)
+		}
+		operands, _ := code.ReadOperands(def, ins[i+1:])
+		if err := visit(i, op, operands); err != nil {
+			return err
+		}
+		i += 1 + width
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package objfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/code"
+	"github.com/eyanshu1997/yacgo/compiler"
+	"github.com/eyanshu1997/yacgo/conformance"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+func compile(t *testing.T, input string) *compiler.Bytecode {
+	bytecode, err := conformance.Compile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytecode
+}
+
+func encode(t *testing.T, input string) []byte {
+	data, err := Encode(compile(t, input))
+	if err != nil {
+		t.Fatalf("encode error for %q: %s", input, err)
+	}
+	return data
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tt := range conformance.Cases {
+		bytecode := compile(t, tt.Input)
+		data, err := Encode(bytecode)
+		if err != nil {
+			t.Fatalf("encode error for %q: %s", tt.Input, err)
+		}
+		decoded, err := Decode(data)
+		if err != nil {
+			t.Fatalf("decode error for %q: %s", tt.Input, err)
+		}
+		if !reflect.DeepEqual(bytecode, decoded) {
+			t.Errorf("bytecode of %q changed.\nexpected=%s\ngot=     %s", tt.Input, bytecode, decoded)
+		}
+	}
+}
+
+func TestRejectsTruncatedFiles(t *testing.T) {
+	data := encode(t, `fn f(x) { x + 1.5 } f(1) + len("abc")`)
+	for n := 0; n < len(data); n++ {
+		_, err := Decode(data[:n])
+		if !errors.Is(err, ErrTruncated) {
+			t.Errorf("a file cut to %d bytes should be truncated. got=%v", n, err)
+		}
+	}
+}
+
+func TestRejects(t *testing.T) {
+	valid := encode(t, "let a = 1; a")
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+		message  string
+	}{
+		{"source file", []byte("let a = 1;"), ErrNotBytecode, "not a yacgo bytecode file"},
+		{"newer version", change(valid, 5, 2), ErrVersion, "unsupported bytecode version 2, expected version 1"},
+		{"damaged body", change(valid, headerSize+2, 0xff), ErrChecksum, "bytecode checksum mismatch"},
+		{"trailing bytes", append(append([]byte{}, valid...), 0), ErrMalformed, "malformed bytecode file: 1 bytes after the end of the file"},
+		{
+			"unknown opcode",
+			withBody(function("", 0, 0, code.Instructions{255}), 0),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 0: opcode 255 undefined",
+		},
+		{
+			"missing constant",
+			withBody(function("", 0, 0, code.Make(code.OpConstant, 0)), 0),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 0: no constant 0",
+		},
+		{
+			"jump into an instruction",
+			withBody(function("", 0, 0, append(code.Make(code.OpJump, 4), code.Make(code.OpArray, 0)...)), 0),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 0: jump to 4 is not to an instruction",
+		},
+		{
+			"name that is not a string",
+			withBody(function("", 0, 0, code.Make(code.OpGetGlobal, 0, 0)), 1, tagInteger, 0, 0, 0, 0, 0, 0, 0, 1),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 0: constant 0 is not a name",
+		},
+		{
+			"unknown constant tag",
+			withBody(function("", 0, 0, nil), 1, 9),
+			ErrMalformed,
+			"malformed bytecode file: constant 0 has the unknown tag 9",
+		},
+		{
+			"closure without its free variables",
+			withBody(append(
+				function("", 0, 0, code.Make(code.OpClosure, 0, 0)),
+				function("f", 0, 0, code.Make(code.OpGetFree, 0, 1))...,
+			), 2, tagFunction, 0, 0, 0, 1, tagString, 0, 0, 0, 1, 'n'),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 0: closure gets 0 free variables, its function uses 1",
+		},
+		{
+			"pop of an empty stack",
+			withBody(function("", 0, 0, code.Make(code.OpPop)), 0),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 0: OpPop pops 1, the stack only has 0",
+		},
+		{
+			"cell that is not one",
+			withBody(function("", 0, 1, concat(code.Make(code.OpNull), code.Make(code.OpSetLocal, 0), code.Make(code.OpGetCell, 0, 0))),
+				1, tagString, 0, 0, 0, 1, 'x'),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 3: OpGetCell needs a cell in local 0, it holds a value",
+		},
+		{
+			"iterator that is not one",
+			withBody(function("", 0, 0, concat(code.Make(code.OpNull), code.Make(code.OpIterNext, 0))), 0),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 1: OpIterNext needs an iterator on top of the stack",
+		},
+		{
+			"stack that grows in a loop",
+			withBody(function("", 0, 0, concat(code.Make(code.OpNull), code.Make(code.OpJump, 0))), 0),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 0: the stack has 0 values on one path and 1 on another",
+		},
+		{
+			"operator on an iterator",
+			withBody(function("", 0, 0, concat(code.Make(code.OpNull), code.Make(code.OpIter), code.Make(code.OpMinus))), 0),
+			ErrMalformed,
+			"malformed bytecode file: function 0: offset 2: OpMinus takes a value, the stack has an iterator",
+		},
+		{
+			"function that runs off its end",
+			withBody(append(
+				function("", 0, 0, code.Make(code.OpClosure, 0, 0)),
+				function("f", 0, 0, code.Make(code.OpNull))...,
+			), 1, tagFunction, 0, 0, 0, 1),
+			ErrMalformed,
+			"malformed bytecode file: function 1: offset 1: the function runs off its end",
+		},
+	}
+	for _, tt := range tests {
+		_, err := Decode(tt.data)
+		if !errors.Is(err, tt.expected) || err.Error() != tt.message {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.message, err)
+		}
+	}
+}
+
+func TestDecodedProgramRuns(t *testing.T) {
+	bytecode, err := Decode(encode(t, "let s = \"a\"; fn f(x) { x * 2 } [f(2), f(0.5), s]"))
+	if err != nil {
+		t.Fatalf("decode error: %s", err)
+	}
+	if s, ok := bytecode.Constants[0].(*object.String); !ok || s.Value != "a" {
+		t.Errorf("wrong string constant %#v", bytecode.Constants[0])
+	}
+	fn, ok := bytecode.Constants[2].(*object.CompiledFunction)
+	if !ok || fn.Name != "f" || fn.NumParameters != 1 || len(fn.Lines) == 0 {
+		t.Errorf("wrong function constant %#v", bytecode.Constants[2])
+	}
+}
+
+func change(data []byte, at int, b byte) []byte {
+	changed := append([]byte{}, data...)
+	changed[at] = b
+	return changed
+}
+
+// a function table entry without line table
+func function(name string, parameters, locals int, ins code.Instructions) []byte {
+	e := &encoder{}
+	e.function(&object.CompiledFunction{Name: name, NumParameters: parameters, NumLocals: locals, Instructions: ins})
+	return e.buf.Bytes()
+}
+
+// a file with a valid header and checksum around the functions, the constant
+// pool is count constants encoded as pool
+func withBody(functions []byte, count int, pool ...byte) []byte {
+	body := binary.BigEndian.AppendUint32(nil, uint32(countFunctions(functions)))
+	body = append(body, functions...)
+	body = binary.BigEndian.AppendUint32(body, uint32(count))
+	body = append(body, pool...)
+	data := append([]byte{}, magic...)
+	data = binary.BigEndian.AppendUint16(data, Version)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(body)))
+	data = append(data, body...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(body))
+}
+
+func countFunctions(functions []byte) int {
+	d := &decoder{data: functions}
+	n := 0
+	for d.offset < len(d.data) && d.err == nil {
+		d.function()
+		n++
+	}
+	return n
+}
+
+func concat(instructions ...code.Instructions) code.Instructions {
+	var out code.Instructions
+	for _, ins := range instructions {
+		out = append(out, ins...)
+	}
+	return out
+}
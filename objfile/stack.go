@@ -0,0 +1,239 @@
+package objfile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eyanshu1997/yacgo/code"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+// what a slot of the stack or a local can hold, a set of these
+type kind uint8
+
+const (
+	value    kind = 1 << iota // an object of the language
+	void                      // the nothing of a program whose last statement has no value
+	cell                      // a variable captured by a closure
+	iterator                  // the state of a for loop
+	unset                     // a local before it is set, whatever the slot held before
+)
+
+var kindNames = []string{"a value", "nothing", "a cell", "an iterator", "an unset local"}
+
+func (k kind) String() string {
+	var names []string
+	for i, name := range kindNames {
+		if k&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// the stack above the locals of the frame and the locals before an instruction
+type state struct {
+	stack  []kind
+	locals []kind
+}
+
+func (s *state) copy() *state {
+	return &state{stack: append([]kind{}, s.stack...), locals: append([]kind{}, s.locals...)}
+}
+
+// merges the state of another path to the same instruction, reports whether s changed
+func (s *state) merge(other *state) (bool, error) {
+	if len(s.stack) != len(other.stack) {
+		return false, fmt.Errorf("the stack has %d values on one path and %d on another", len(s.stack), len(other.stack))
+	}
+	changed := false
+	for i, k := range other.stack {
+		if s.stack[i]|k != s.stack[i] {
+			s.stack[i] |= k
+			changed = true
+		}
+	}
+	for i, k := range other.locals {
+		if s.locals[i]|k != s.locals[i] {
+			s.locals[i] |= k
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// pops n values that each have to be one of the allowed kinds
+func (s *state) pop(n int, allowed kind) error {
+	if len(s.stack) < n {
+		return fmt.Errorf("pops %d, the stack only has %d", n, len(s.stack))
+	}
+	for _, k := range s.stack[len(s.stack)-n:] {
+		if k&^allowed != 0 {
+			return fmt.Errorf("takes %s, the stack has %s", allowed, k)
+		}
+	}
+	s.stack = s.stack[:len(s.stack)-n]
+	return nil
+}
+
+func (s *state) push(k kind) {
+	s.stack = append(s.stack, k)
+}
+
+func (s *state) local(index int, allowed kind) error {
+	if k := s.locals[index]; k&^allowed != 0 {
+		return fmt.Errorf("needs %s in local %d, it holds %s", allowed, index, k)
+	}
+	return nil
+}
+
+// makes sure fn does not break the stack: every path through it is followed
+// with what each slot of the stack and each local can hold. an instruction finds
+// the values it takes, the stack has the same depth on every path to an
+// instruction so it cannot grow without end, and only the program runs off its
+// end, leaving its value. check has to accept fn first
+func checkStack(fn *object.CompiledFunction, program bool) error {
+	entry := &state{locals: make([]kind, fn.NumLocals)}
+	for i := range entry.locals {
+		entry.locals[i] = unset
+		if i < fn.NumParameters {
+			entry.locals[i] = value
+		}
+	}
+	states := map[int]*state{0: entry}
+	work := []int{0}
+	for len(work) > 0 {
+		offset := work[len(work)-1]
+		work = work[:len(work)-1]
+		s := states[offset].copy()
+		if offset == len(fn.Instructions) {
+			if !program {
+				return fmt.Errorf("offset %d: the function runs off its end", offset)
+			}
+			if err := s.pop(1, value|void); err != nil {
+				return fmt.Errorf("offset %d: the end of the program %s", offset, err)
+			}
+			continue
+		}
+		op := code.Opcode(fn.Instructions[offset])
+		def, _ := code.Lookup(op)
+		operands, width := code.ReadOperands(def, fn.Instructions[offset+1:])
+		next, err := step(s, op, operands, program)
+		if err != nil {
+			return fmt.Errorf("offset %d: %s %s", offset, def.Name, err)
+		}
+		for _, b := range next {
+			target := b.target
+			if target == fallThrough {
+				target = offset + 1 + width
+			}
+			known, ok := states[target]
+			if !ok {
+				states[target] = b.state
+				work = append(work, target)
+				continue
+			}
+			changed, err := known.merge(b.state)
+			if err != nil {
+				return fmt.Errorf("offset %d: %s", target, err)
+			}
+			if changed {
+				work = append(work, target)
+			}
+		}
+	}
+	return nil
+}
+
+// an instruction that can run next and the state it starts with
+type branch struct {
+	target int
+	state  *state
+}
+
+// the target of the instruction after the one stepped over
+const fallThrough = -1
+
+// runs op on s, the result are the instructions that can run next
+func step(s *state, op code.Opcode, operands []int, program bool) ([]branch, error) {
+	next := []branch{{fallThrough, s}}
+	var err error
+	switch op {
+	case code.OpConstant, code.OpNull, code.OpTrue, code.OpFalse, code.OpGetGlobal, code.OpGetFree, code.OpGetBuiltin:
+		s.push(value)
+	case code.OpVoid:
+		s.push(void)
+	case code.OpPop:
+		err = s.pop(1, value|void|cell|iterator)
+	case code.OpMinus, code.OpBang, code.OpBitNot:
+		err = s.pop(1, value)
+		s.push(value)
+	case code.OpJump:
+		return []branch{{operands[0], s}}, nil
+	case code.OpJumpNotTruthy:
+		if err = s.pop(1, value); err == nil {
+			next = append(next, branch{operands[0], s.copy()})
+		}
+	case code.OpSetGlobal, code.OpSetFree:
+		err = s.pop(1, value)
+	case code.OpGetLocal:
+		err = s.local(operands[0], value)
+		s.push(value)
+	case code.OpSetLocal:
+		err = s.pop(1, value)
+		s.locals[operands[0]] = value
+	case code.OpNewCell:
+		s.locals[operands[0]] = cell
+	case code.OpGetCell:
+		err = s.local(operands[0], cell)
+		s.push(value)
+	case code.OpSetCell:
+		if err = s.local(operands[0], cell); err == nil {
+			err = s.pop(1, value)
+		}
+	case code.OpLoadCell:
+		err = s.local(operands[0], cell)
+		s.push(cell)
+	case code.OpLoadFree:
+		s.push(cell)
+	case code.OpArray:
+		err = s.pop(operands[0], value)
+		s.push(value)
+	case code.OpHash:
+		if operands[0]%2 != 0 {
+			return nil, fmt.Errorf("takes pairs, got %d values", operands[0])
+		}
+		err = s.pop(operands[0], value)
+		s.push(value)
+	case code.OpCall:
+		err = s.pop(operands[0]+1, value)
+		s.push(value)
+	case code.OpReturnValue:
+		if program {
+			err = s.pop(1, value|void)
+		} else {
+			err = s.pop(1, value)
+		}
+		next = nil
+	case code.OpClosure:
+		err = s.pop(operands[1], cell)
+		s.push(value)
+	case code.OpIter:
+		err = s.pop(1, value)
+		s.push(iterator)
+	case code.OpIterNext:
+		if len(s.stack) == 0 || s.stack[len(s.stack)-1] != iterator {
+			return nil, fmt.Errorf("needs an iterator on top of the stack")
+		}
+		next = append(next, branch{operands[0], s.copy()})
+		s.push(value)
+	default:
+		// the binary operators
+		err = s.pop(2, value)
+		s.push(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
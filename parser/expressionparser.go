@@ -1,35 +1,74 @@
 package parser
 
 import (
-	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/eyanshu1997/yacgo/ast"
 	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/diagnostic"
 	"github.com/eyanshu1997/yacgo/tokens"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
 	EQUALS      // ==
-	LESSGREATER // > or <
+	LESSGREATER // > or < or <= or >=
+	BITWISE_OR  // |
+	BITWISE_XOR // ^
+	BITWISE_AND // &
+	SHIFT       // << or >>
 	SUM         // +
-	PRODUCT     // *
-	PREFIX      // -X or !X
+	PRODUCT     // * or / or %
+	PREFIX      // -X or !X or ~X
+	POWER       // X ** Y, tighter than prefix so -2 ** 2 is -(2 ** 2)
 	CALL        // myFunction(X)
+	INDEX       // array[index]
 )
 
 var precedences = map[tokens.TokenType]int{
+	tokens.TokenTypeOr:       LOGICAL_OR,
+	tokens.TokenTypeAnd:      LOGICAL_AND,
 	tokens.TokenTypeEQ:       EQUALS,
 	tokens.TokenTypeNotEQ:    EQUALS,
 	tokens.TokenTypeLT:       LESSGREATER,
 	tokens.TokenTypeGT:       LESSGREATER,
+	tokens.TokenTypeLTE:      LESSGREATER,
+	tokens.TokenTypeGTE:      LESSGREATER,
+	tokens.TokenTypeBitOr:    BITWISE_OR,
+	tokens.TokenTypeBitXor:   BITWISE_XOR,
+	tokens.TokenTypeBitAnd:   BITWISE_AND,
+	tokens.TokenTypeShl:      SHIFT,
+	tokens.TokenTypeShr:      SHIFT,
 	tokens.TokenTypePlus:     SUM,
 	tokens.TokenTypeSubtract: SUM,
 	tokens.TokenTypeDivide:   PRODUCT,
 	tokens.TokenTypeAstrisk:  PRODUCT,
+	tokens.TokenTypeModulo:   PRODUCT,
+	tokens.TokenTypePower:    POWER,
 	tokens.TokenTypeLParen:   CALL,
+	tokens.TokenTypeLBracket: INDEX,
+}
+
+// operators that group to the right, 2 ** 3 ** 2 is 2 ** (3 ** 2)
+var rightAssociative = map[tokens.TokenType]bool{
+	tokens.TokenTypePower: true,
+}
+
+// RightAssociative reports whether the infix operator token groups to the right
+func RightAssociative(t tokens.TokenType) bool {
+	return rightAssociative[t]
+}
+
+// Precedence is the binding power of an infix operator token, LOWEST for any other token
+func Precedence(t tokens.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -60,16 +99,41 @@ func (p *Parser) parseIdentifier() ast.Expression {
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	// the lexer has checked the separators, a number without a 0x, 0o or 0b
+	// prefix is decimal even with leading zeros
+	digits := strings.ReplaceAll(p.curToken.Literal, "_", "")
+	base := 10
+	if len(digits) > 1 && digits[0] == '0' && strings.ContainsAny(digits[1:2], "xXoObB") {
+		base = 0
+	}
+	value, err := strconv.ParseInt(digits, base, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
-		return nil
+		d := diagnostic.NewError(diagnostic.CodeInvalidInteger, p.curToken.Start, p.curToken.End,
+			"could not parse %q as integer", p.curToken.Literal)
+		p.addError(d)
+		return &ast.BadExpression{Token: p.curToken}
+	}
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(p.curToken.Literal, "_", ""), 64)
+	if err != nil {
+		d := diagnostic.NewError(diagnostic.CodeInvalidFloat, p.curToken.Start, p.curToken.End,
+			"could not parse %q as float", p.curToken.Literal)
+		p.addError(d)
+		return &ast.BadExpression{Token: p.curToken}
 	}
 	lit.Value = value
 	return lit
 }
 
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -84,13 +148,23 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
 	p.nextToken()
+	p.blockDepths = append(p.blockDepths, p.braceDepth)
+	defer func() { p.blockDepths = p.blockDepths[:len(p.blockDepths)-1] }()
 	for !p.curTokenIs(tokens.TokenTypeRBrace) && !p.curTokenIs(tokens.TokenTypeEOF) {
-		stmt := p.parseStatement()
+		stmt := p.parseStatementWithRecovery()
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
-		p.nextToken()
 	}
+	if !p.curTokenIs(tokens.TokenTypeRBrace) {
+		d := diagnostic.NewError(diagnostic.CodeUnexpectedToken, p.curToken.Start, p.curToken.End,
+			"expected %s to close the block, got %s instead", tokens.TokenTypeRBrace, p.curToken.Type)
+		d.Expected = []tokens.TokenType{tokens.TokenTypeRBrace}
+		d.Found = p.curToken.Type
+		p.addError(d)
+		return block
+	}
+	block.RBrace = p.curToken
 	return block
 }
 
@@ -101,6 +175,10 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 	precedence := p.curPrecedence()
+	if rightAssociative[p.curToken.Type] {
+		// lets an operator of the same precedence take the right side
+		precedence--
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 	return expression
@@ -111,17 +189,20 @@ func (p *Parser) parseBoolean() ast.Expression {
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	start := p.curToken
 	p.nextToken()
 	exp := p.parseExpression(LOWEST)
 	if !p.expectPeek(tokens.TokenTypeRParen) {
-		return nil
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
 	}
 	return exp
 }
 
 func (p *Parser) noPrefixParseFnError(t tokens.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	d := diagnostic.NewError(diagnostic.CodeNoPrefixParseFn, p.curToken.Start, p.curToken.End,
+		"no prefix parse function for %s found", t)
+	d.Found = t
+	p.addError(d)
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
@@ -129,7 +210,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
-		return nil
+		return &ast.BadExpression{Token: p.curToken}
 	}
 	leftExp := prefix()
 	log.Printf("parseExpression after prefixParseFns leftExp [%s] currtoen[%s] peektokenType[%s][%d]", leftExp, p.curToken, p.peekToken, p.peekPrecedence())
@@ -146,27 +227,119 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	return leftExp
 }
 
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+	if !p.expectPeek(tokens.TokenTypeLParen) {
+		return &ast.BadExpression{Token: lit.Token, To: p.curToken.End}
+	}
+	lit.Parameters = p.parseFunctionParameters()
+	if !p.expectPeek(tokens.TokenTypeLBrace) {
+		return &ast.BadExpression{Token: lit.Token, To: p.curToken.End}
+	}
+	lit.Body = p.parseFunctionBody()
+	return lit
+}
+
+// a loop around a function does not let its body break out of the loop
+func (p *Parser) parseFunctionBody() *ast.BlockStatement {
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	defer func() { p.loopDepth = loopDepth }()
+	return p.parseBlockStatement()
+}
+
+// parses `(a, b)`, curToken is the ( and is left at the )
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+	if p.peekTokenIs(tokens.TokenTypeRParen) {
+		p.nextToken()
+		return identifiers
+	}
+	if !p.expectPeek(tokens.TokenTypeIdentifier) {
+		return nil
+	}
+	identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	for p.peekTokenIs(tokens.TokenTypeComma) {
+		p.nextToken()
+		if !p.expectPeek(tokens.TokenTypeIdentifier) {
+			return nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+	if !p.expectPeek(tokens.TokenTypeRParen) {
+		return nil
+	}
+	return identifiers
+}
+
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	log.Printf("Call expresion called %s token:[%s]", function, p.curToken)
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseCallArguments()
+	exp.Arguments = p.parseExpressionList(tokens.TokenTypeRParen)
+	if exp.Arguments != nil {
+		exp.RParen = p.curToken
+	}
 	return exp
 }
-func (p *Parser) parseCallArguments() []ast.Expression {
-	args := []ast.Expression{}
-	if p.peekTokenIs(tokens.TokenTypeRParen) {
+
+// parses comma separated expressions up to end, curToken is left at end
+func (p *Parser) parseExpressionList(end tokens.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+	if p.peekTokenIs(end) {
 		p.nextToken()
-		return args
+		return list
 	}
 	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	list = append(list, p.parseExpression(LOWEST))
 	for p.peekTokenIs(tokens.TokenTypeComma) {
 		p.nextToken()
 		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
+		list = append(list, p.parseExpression(LOWEST))
 	}
-	if !p.expectPeek(tokens.TokenTypeRParen) {
+	if !p.expectPeek(end) {
 		return nil
 	}
-	return args
+	return list
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(tokens.TokenTypeRBracket)
+	if array.Elements != nil {
+		array.RBracket = p.curToken
+	}
+	return array
+}
+
+// `{` only starts a hash in expression position, after if and fn it starts a block
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Pairs = []*ast.HashPair{}
+	for !p.peekTokenIs(tokens.TokenTypeRBrace) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+		if !p.expectPeek(tokens.TokenTypeColon) {
+			return hash
+		}
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+		hash.Pairs = append(hash.Pairs, &ast.HashPair{Key: key, Value: value})
+		if !p.peekTokenIs(tokens.TokenTypeRBrace) && !p.expectPeek(tokens.TokenTypeComma) {
+			return hash
+		}
+	}
+	p.nextToken()
+	hash.RBrace = p.curToken
+	return hash
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+	if !p.expectPeek(tokens.TokenTypeRBracket) {
+		return exp
+	}
+	exp.RBracket = p.curToken
+	return exp
 }
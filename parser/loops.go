@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+// `while (cond) { ... }`
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+	if !p.expectPeek(tokens.TokenTypeLParen) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+	if !p.expectPeek(tokens.TokenTypeRParen) {
+		return nil
+	}
+	if !p.expectPeek(tokens.TokenTypeLBrace) {
+		return nil
+	}
+	log.Printf("Found while statement [%s]", stmt.Condition)
+	stmt.Body = p.parseLoopBody()
+	return stmt
+}
+
+// `for x in iterable { ... }`
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+	if !p.expectPeek(tokens.TokenTypeIdentifier) {
+		return nil
+	}
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(tokens.TokenTypeIn) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+	if !p.expectPeek(tokens.TokenTypeLBrace) {
+		return nil
+	}
+	log.Printf("Found for statement [%s] in [%s]", stmt.Variable, stmt.Iterable)
+	stmt.Body = p.parseLoopBody()
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+	return p.parseBlockStatement()
+}
+
+func (p *Parser) parseBreakStatement() ast.Statement {
+	stmt := &ast.BreakStatement{Token: p.curToken}
+	p.checkInLoop()
+	if !p.expectPeek(tokens.TokenTypeSemiColon) {
+		return nil
+	}
+	stmt.Semicolon = p.curToken
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() ast.Statement {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+	p.checkInLoop()
+	if !p.expectPeek(tokens.TokenTypeSemiColon) {
+		return nil
+	}
+	stmt.Semicolon = p.curToken
+	return stmt
+}
+
+// break and continue only make sense in a loop of the function they are in.
+// the statement itself is well formed so the parser does not need to recover
+func (p *Parser) checkInLoop() {
+	if p.loopDepth > 0 {
+		return
+	}
+	d := diagnostic.NewError(diagnostic.CodeOutsideLoop, p.curToken.Start, p.curToken.End,
+		"%s is not in a loop", p.curToken.Literal)
+	log.Println(d.Error())
+	p.errors = append(p.errors, d)
+}
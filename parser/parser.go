@@ -3,6 +3,7 @@ package parser
 import (
 	"github.com/eyanshu1997/yacgo/ast"
 	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/diagnostic"
 	"github.com/eyanshu1997/yacgo/lexer"
 	"github.com/eyanshu1997/yacgo/tokens"
 )
@@ -12,38 +13,75 @@ type (
 	infixParseFn  func(ast.Expression) ast.Expression
 )
 
+// TokenSource produces the tokens the parser consumes, *lexer.Lexer is one.
+// ReadNextToken keeps returning an EOF token once the input has ended, Errors
+// are the problems found while producing the tokens
+type TokenSource interface {
+	ReadNextToken() *tokens.Token
+	Errors() []*diagnostic.Diagnostic
+}
+
+// the lexer is the default token source
+var _ TokenSource = (*lexer.Lexer)(nil)
+
 type Parser struct {
-	l              *lexer.Lexer
+	l              TokenSource
+	lastToken      tokens.Token
 	curToken       tokens.Token
 	peekToken      tokens.Token
-	errors         []string
+	errors         []*diagnostic.Diagnostic
+	panicking      bool  // an error was found and the parser has not synchronized yet
+	stmtErrors     int   // errors found in the current top level statement
+	braceDepth     int   // unclosed { before curToken
+	blockDepths    []int // braceDepth inside each block being parsed
+	comments       []*ast.Comment
+	loopDepth      int // loops around curToken in the current function, break and continue need one
 	prefixParseFns map[tokens.TokenType]prefixParseFn
 	infixParseFns  map[tokens.TokenType]infixParseFn
 }
 
-func NewParser(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+func NewParser(l TokenSource) *Parser {
+	p := &Parser{l: l, errors: []*diagnostic.Diagnostic{}}
 	p.nextToken()
 	p.nextToken()
 	// Read two tokens, so curToken and peekToken are both set
 	p.prefixParseFns = make(map[tokens.TokenType]prefixParseFn)
 	p.registerPrefix(tokens.TokenTypeIdentifier, p.parseIdentifier)
 	p.registerPrefix(tokens.TokenTypeInt, p.parseIntegerLiteral)
+	p.registerPrefix(tokens.TokenTypeFloat, p.parseFloatLiteral)
+	p.registerPrefix(tokens.TokenTypeString, p.parseStringLiteral)
 	p.registerPrefix(tokens.TokenTypeExclaim, p.parsePrefixExpression)
 	p.registerPrefix(tokens.TokenTypeSubtract, p.parsePrefixExpression)
+	p.registerPrefix(tokens.TokenTypeBitNot, p.parsePrefixExpression)
 	p.infixParseFns = make(map[tokens.TokenType]infixParseFn)
 	p.registerInfix(tokens.TokenTypePlus, p.parseInfixExpression)
 	p.registerInfix(tokens.TokenTypeSubtract, p.parseInfixExpression)
 	p.registerInfix(tokens.TokenTypeDivide, p.parseInfixExpression)
 	p.registerInfix(tokens.TokenTypeAstrisk, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeModulo, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypePower, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeBitAnd, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeBitOr, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeBitXor, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeShl, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeShr, p.parseInfixExpression)
 	p.registerInfix(tokens.TokenTypeEQ, p.parseInfixExpression)
 	p.registerInfix(tokens.TokenTypeNotEQ, p.parseInfixExpression)
 	p.registerInfix(tokens.TokenTypeLT, p.parseInfixExpression)
 	p.registerInfix(tokens.TokenTypeGT, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeLTE, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeGTE, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeAnd, p.parseInfixExpression)
+	p.registerInfix(tokens.TokenTypeOr, p.parseInfixExpression)
 	p.registerPrefix(tokens.TokenTypeTrue, p.parseBoolean)
 	p.registerPrefix(tokens.TokenTypeFalse, p.parseBoolean)
 	p.registerPrefix(tokens.TokenTypeLParen, p.parseGroupedExpression)
+	p.registerPrefix(tokens.TokenTypeFunction, p.parseFunctionLiteral)
+	p.registerPrefix(tokens.TokenTypeIf, p.parseIfExpression)
 	p.registerInfix(tokens.TokenTypeLParen, p.parseCallExpression)
+	p.registerPrefix(tokens.TokenTypeLBracket, p.parseArrayLiteral)
+	p.registerInfix(tokens.TokenTypeLBracket, p.parseIndexExpression)
+	p.registerPrefix(tokens.TokenTypeLBrace, p.parseHashLiteral)
 
 	return p
 }
@@ -68,6 +106,22 @@ func (p *Parser) parseIfStatement() ast.Statement {
 
 		p.nextToken()
 		log.Printf("Looking in else block curtoken [%s]", p.curToken)
+		if p.peekTokenIs(tokens.TokenTypeIf) {
+			// else if ... is an else block holding just the next if
+			p.nextToken()
+			block := &ast.BlockStatement{Token: p.curToken}
+			elseIf := p.parseIfStatement()
+			if elseIf == nil {
+				if p.curToken.Start == block.Token.Start {
+					// step over the if so recovery does not take it for the start of the next statement
+					p.nextToken()
+				}
+				return nil
+			}
+			block.Statements = []ast.Statement{elseIf}
+			expression.Alternative = block
+			return expression
+		}
 		if !p.expectPeek(tokens.TokenTypeLBrace) {
 			return nil
 		}
@@ -77,6 +131,22 @@ func (p *Parser) parseIfStatement() ast.Statement {
 	return expression
 }
 
+// an if used as a value, `let x = if (a) { 1 } else { 2 };`. an if at the start
+// of a statement is an if statement
+func (p *Parser) parseIfExpression() ast.Expression {
+	start := p.curToken
+	stmt, ok := p.parseIfStatement().(*ast.IfStatement)
+	if !ok {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	return &ast.IfExpression{
+		Token:       stmt.Token,
+		Condition:   stmt.Condition,
+		Consequence: stmt.Consequence,
+		Alternative: stmt.Alternative,
+	}
+}
+
 func (p *Parser) parseLetStatement() ast.Statement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 	if !p.expectPeek(tokens.TokenTypeIdentifier) {
@@ -92,6 +162,7 @@ func (p *Parser) parseLetStatement() ast.Statement {
 	if !p.expectPeek(tokens.TokenTypeSemiColon) {
 		return nil
 	}
+	stmt.Semicolon = p.curToken
 	return stmt
 }
 
@@ -103,26 +174,59 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 	if !p.expectPeek(tokens.TokenTypeSemiColon) {
 		return nil
 	}
+	stmt.Semicolon = p.curToken
 	return stmt
 }
 
+// `fn add(a, b) { ... }` declares add, anything else starting with fn is a function literal
 func (p *Parser) parseFunctionStatement() ast.Statement {
+	if !p.peekTokenIs(tokens.TokenTypeIdentifier) {
+		return p.parseExpressionStatement()
+	}
 	stmt := &ast.FunctionStatement{Token: p.curToken}
-	//TODO implement this
+	p.nextToken()
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	log.Printf("Found function statement [%s]", stmt.Name)
+	if !p.expectPeek(tokens.TokenTypeLParen) {
+		return nil
+	}
+	stmt.Parameters = p.parseFunctionParameters()
+	if !p.expectPeek(tokens.TokenTypeLBrace) {
+		return nil
+	}
+	stmt.Body = p.parseFunctionBody()
 	return stmt
 }
 
+// `x = 1;` is an assignment, any other statement starting with an identifier is an expression
 func (p *Parser) parseIdentifierStatement() ast.Statement {
-	stmt := &ast.AssignmentStatement{Token: p.curToken}
-	if !p.expectPeek(tokens.TokenTypeAssign) {
-		return nil
+	if !p.peekTokenIs(tokens.TokenTypeAssign) {
+		return p.parseExpressionStatement()
 	}
+	stmt := &ast.AssignmentStatement{Token: p.curToken}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.nextToken()
 	p.nextToken()
 	log.Printf("Found assignment statement [%s]: [%s]", stmt, p.curToken)
 	stmt.Value = p.parseExpression(LOWEST)
 	if !p.expectPeek(tokens.TokenTypeSemiColon) {
 		return nil
 	}
+	stmt.Semicolon = p.curToken
+	return stmt
+}
+
+// a bare expression like `add(1, 2);`, the trailing semicolon is optional so the repl can evaluate `x`
+func (p *Parser) parseExpressionStatement() ast.Statement {
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	stmt.Expression = p.parseExpression(LOWEST)
+	if stmt.Expression == nil {
+		return nil
+	}
+	if p.peekTokenIs(tokens.TokenTypeSemiColon) {
+		p.nextToken()
+		stmt.Semicolon = p.curToken
+	}
 	return stmt
 }
 
@@ -137,10 +241,28 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseFunctionStatement()
 	case tokens.TokenTypeIf:
 		return p.parseIfStatement()
+	case tokens.TokenTypeWhile:
+		return p.parseWhileStatement()
+	case tokens.TokenTypeFor:
+		return p.parseForStatement()
+	case tokens.TokenTypeBreak:
+		return p.parseBreakStatement()
+	case tokens.TokenTypeContinue:
+		return p.parseContinueStatement()
 	case tokens.TokenTypeIdentifier:
 		return p.parseIdentifierStatement()
-	default:
+	case tokens.TokenTypeSemiColon:
+		// an empty statement, like the ; after `fn g(x) { x };` or in `x = 1;;`
 		return nil
+	default:
+		if p.prefixParseFns[p.curToken.Type] == nil {
+			d := diagnostic.NewError(diagnostic.CodeExpectedStmt, p.curToken.Start, p.curToken.End,
+				"expected a statement, got %s instead", p.curToken.Type)
+			d.Found = p.curToken.Type
+			p.addError(d)
+			return nil
+		}
+		return p.parseExpressionStatement()
 	}
 }
 
@@ -149,12 +271,16 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 	for p.curToken.Type != tokens.TokenTypeEOF {
-		stmt := p.parseStatement()
+		p.stmtErrors = 0
+		stmt := p.parseStatementWithRecovery()
 		if stmt != nil {
 			log.Printf("Got statement %s", stmt)
 			program.Statements = append(program.Statements, stmt)
 		}
-		p.nextToken()
+	}
+	if len(p.comments) > 0 {
+		program.Comments = p.comments
+		program.CommentMap = ast.NewCommentMap(program, p.comments)
 	}
 	return program
 }
@@ -2,11 +2,14 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/eyanshu1997/yacgo/ast"
 	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/diagnostic"
 	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/tokens"
 )
 
 func TestLetStatements(t *testing.T) {
@@ -213,6 +216,74 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"i =!(true == true);",
 			"i = (!(true == true));",
 		},
+		{
+			"i = a * [1, 2, 3, 4][b * c] * d;",
+			"i = ((a * ([1, 2, 3, 4][(b * c)])) * d);",
+		},
+		{
+			"i = add(a * b[2], b[1], 2 * [1, 2][1]);",
+			"i = add((a * (b[2])), (b[1]), (2 * ([1, 2][1])));",
+		},
+		{
+			"i = f(x)[0];",
+			"i = (f(x)[0]);",
+		},
+		{
+			"i = a <= b == c >= d;",
+			"i = ((a <= b) == (c >= d));",
+		},
+		{
+			"i = a || b && c;",
+			"i = (a || (b && c));",
+		},
+		{
+			"i = a && b || c && d;",
+			"i = ((a && b) || (c && d));",
+		},
+		{
+			"i = a == b && c != d || !e;",
+			"i = (((a == b) && (c != d)) || (!e));",
+		},
+		{
+			"i = a + 1 < b * 2 && c;",
+			"i = (((a + 1) < (b * 2)) && c);",
+		},
+		{
+			"i = 2 ** 3 ** 2;",
+			"i = (2 ** (3 ** 2));",
+		},
+		{
+			"i = -2 ** 2;",
+			"i = (-(2 ** 2));",
+		},
+		{
+			"i = 2 ** -1 * 3;",
+			"i = ((2 ** (-1)) * 3);",
+		},
+		{
+			"i = a * b % c;",
+			"i = ((a * b) % c);",
+		},
+		{
+			"i = a | b ^ c & d;",
+			"i = (a | (b ^ (c & d)));",
+		},
+		{
+			"i = a & b == c;",
+			"i = ((a & b) == c);",
+		},
+		{
+			"i = 1 << 2 + 3 >> 1;",
+			"i = ((1 << (2 + 3)) >> 1);",
+		},
+		{
+			"i = ~a & ~b;",
+			"i = ((~a) & (~b));",
+		},
+		{
+			"i = a[0] ** f(2);",
+			"i = ((a[0]) ** f(2));",
+		},
 	}
 	for i, tt := range tests {
 		l := lexer.NewLexer(tt.input)
@@ -480,3 +551,869 @@ func TestIfElseExpression(t *testing.T) {
 		return
 	}
 }
+
+func TestErrorPositions(t *testing.T) {
+	input := "let x = 5;\nlet y 10;"
+	l := lexer.NewLexer(input, lexer.WithFilename("test.yapl"))
+	p := NewParser(l)
+	p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+	expected := "test.yapl:2:7: expected next token to be =, got INT instead"
+	if errors[0].Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0])
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	input := "let x = add(1, 2);\nif (x < 3) {\n  x = 1;\n}"
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	tests := []struct {
+		node          ast.Node
+		expectedStart string
+		expectedEnd   string
+	}{
+		{program, "1:1", "4:2"},
+		{program.Statements[0], "1:1", "1:19"},
+		{program.Statements[0].(*ast.LetStatement).Value, "1:9", "1:18"},
+		{program.Statements[1], "2:1", "4:2"},
+		{program.Statements[1].(*ast.IfStatement).Condition, "2:5", "2:10"},
+		{program.Statements[1].(*ast.IfStatement).Consequence.Statements[0], "3:3", "3:9"},
+	}
+	for i, tt := range tests {
+		if tt.node.Pos().String() != tt.expectedStart {
+			t.Errorf("tests[%d] - %s start wrong. expected=%s, got=%s",
+				i, tt.node, tt.expectedStart, tt.node.Pos())
+		}
+		if tt.node.End().String() != tt.expectedEnd {
+			t.Errorf("tests[%d] - %s end wrong. expected=%s, got=%s",
+				i, tt.node, tt.expectedEnd, tt.node.End())
+		}
+	}
+}
+
+func TestBadExpressionSpans(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedStart string
+		expectedEnd   string
+	}{
+		{"(1 + 2 3", "1:1", "1:7"},
+		{"if (a) x", "1:1", "1:7"},
+		{"fn x", "1:1", "1:3"},
+		{"fn(a, b) x", "1:1", "1:9"},
+	}
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		exp := p.parseExpression(LOWEST)
+		if _, ok := exp.(*ast.BadExpression); !ok {
+			t.Errorf("%q is not ast.BadExpression. got=%T", tt.input, exp)
+			continue
+		}
+		if len(p.Errors()) != 1 {
+			t.Errorf("expected a single error for %q. got=%v", tt.input, p.Errors())
+		}
+		if exp.Pos().String() != tt.expectedStart || exp.End().String() != tt.expectedEnd {
+			t.Errorf("wrong span for %q. expected=%s-%s, got=%s-%s",
+				tt.input, tt.expectedStart, tt.expectedEnd, exp.Pos(), exp.End())
+		}
+	}
+}
+
+func TestDiagnosticFields(t *testing.T) {
+	l := lexer.NewLexer("let x = 5 return")
+	p := NewParser(l)
+	p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+	d := errors[0]
+	if d.Severity != diagnostic.SeverityError {
+		t.Errorf("wrong severity. got=%s", d.Severity)
+	}
+	if d.Code != diagnostic.CodeUnexpectedToken {
+		t.Errorf("wrong code. got=%s", d.Code)
+	}
+	if len(d.Expected) != 1 || d.Expected[0] != tokens.TokenTypeSemiColon {
+		t.Errorf("wrong expected tokens. got=%v", d.Expected)
+	}
+	if d.Found != tokens.TokenTypeReturn {
+		t.Errorf("wrong found token. got=%s", d.Found)
+	}
+	if d.Start.String() != "1:11" || d.End.String() != "1:17" {
+		t.Errorf("wrong span. got=%s-%s", d.Start, d.End)
+	}
+	if d.Hint == "" {
+		t.Errorf("expected a hint for a missing semicolon")
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+		expectedTypes  []string
+	}{
+		{
+			"let x 5; let y = 10;",
+			[]string{"1:7: expected next token to be =, got INT instead"},
+			[]string{"*ast.BadStatement", "*ast.LetStatement"},
+		},
+		{
+			"let z = 1 +; return z;",
+			[]string{"1:12: no prefix parse function for ; found"},
+			[]string{"*ast.BadStatement", "*ast.ReturnStatement"},
+		},
+		{
+			"let a = 5 return a;",
+			[]string{"1:11: expected next token to be ;, got RETURN instead"},
+			[]string{"*ast.BadStatement", "*ast.ReturnStatement"},
+		},
+		{
+			"if (x) { let = 1; y = 2; } let b = 1;",
+			[]string{"1:14: expected next token to be IDENTIFIER, got = instead"},
+			[]string{"*ast.IfStatement", "*ast.LetStatement"},
+		},
+		{
+			"if (x) { y = ; } let b = ;",
+			[]string{"1:14: no prefix parse function for ; found", "1:26: no prefix parse function for ; found"},
+			[]string{"*ast.IfStatement", "*ast.BadStatement"},
+		},
+		{
+			") let b = 2;",
+			[]string{"1:1: expected a statement, got ) instead"},
+			[]string{"*ast.BadStatement", "*ast.LetStatement"},
+		},
+		{
+			`if (x) { let h = {"a" 1}; y = 1; } let b = 2;`,
+			[]string{"1:23: expected next token to be :, got INT instead"},
+			[]string{"*ast.IfStatement", "*ast.LetStatement"},
+		},
+		{
+			"if (x) { y = 1;",
+			[]string{"1:16: expected } to close the block, got EOF instead"},
+			[]string{"*ast.BadStatement"},
+		},
+	}
+	for i, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("test [%d] wrong number of errors. expected=%d, got=%d %v",
+				i, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+		for j, msg := range tt.expectedErrors {
+			if errors[j].Error() != msg {
+				t.Errorf("test [%d] wrong error. expected=%q, got=%q", i, msg, errors[j].Error())
+			}
+		}
+		if len(program.Statements) != len(tt.expectedTypes) {
+			t.Errorf("test [%d] wrong number of statements. expected=%d, got=%d",
+				i, len(tt.expectedTypes), len(program.Statements))
+			continue
+		}
+		for j, typ := range tt.expectedTypes {
+			if got := fmt.Sprintf("%T", program.Statements[j]); got != typ {
+				t.Errorf("test [%d] statement %d wrong type. expected=%s, got=%s", i, j, typ, got)
+			}
+		}
+	}
+}
+
+func TestErrorsPerStatementCap(t *testing.T) {
+	input := `if (x) {
+		let = 1;
+		let = 2;
+		let = 3;
+		let = 4;
+		let = 5;
+	}`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != maxErrorsPerStatement {
+		t.Errorf("expected %d errors, got=%d", maxErrorsPerStatement, len(p.Errors()))
+	}
+	stmt, ok := program.Statements[0].(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.IfStatement. got=%T", program.Statements[0])
+	}
+	if len(stmt.Consequence.Statements) != 5 {
+		t.Errorf("consequence does not have 5 statements. got=%d", len(stmt.Consequence.Statements))
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `let add = fn(x, y) { return x + y; };`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T",
+			program.Statements[0])
+	}
+	function, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.FunctionLiteral. got=%T", stmt.Value)
+	}
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d\n",
+			len(function.Parameters))
+	}
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
+			len(function.Body.Statements))
+	}
+	bodyStmt, ok := function.Body.Statements[0].(*ast.ReturnStatement)
+	if !ok {
+		t.Fatalf("function body stmt is not ast.ReturnStatement. got=%T",
+			function.Body.Statements[0])
+	}
+	testInfixExpression(t, bodyStmt.ReturnValue, "x", "+", "y")
+}
+
+func TestFunctionParameterParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+	}{
+		{input: "let f = fn() {};", expectedParams: []string{}},
+		{input: "let f = fn(x) {};", expectedParams: []string{"x"}},
+		{input: "let f = fn(x, y, z) {};", expectedParams: []string{"x", "y", "z"}},
+	}
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		stmt := program.Statements[0].(*ast.LetStatement)
+		function := stmt.Value.(*ast.FunctionLiteral)
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d\n",
+				len(tt.expectedParams), len(function.Parameters))
+		}
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+	}
+}
+
+func TestFunctionStatementParsing(t *testing.T) {
+	input := `fn add(a, b) { return a + b; }`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.FunctionStatement. got=%T",
+			program.Statements[0])
+	}
+	if !testIdentifier(t, stmt.Name, "add") {
+		return
+	}
+	if len(stmt.Parameters) != 2 {
+		t.Fatalf("function parameters wrong. want 2, got=%d\n", len(stmt.Parameters))
+	}
+	testLiteralExpression(t, stmt.Parameters[0], "a")
+	testLiteralExpression(t, stmt.Parameters[1], "b")
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("stmt.Body.Statements has not 1 statements. got=%d\n",
+			len(stmt.Body.Statements))
+	}
+}
+
+func TestFunctionCallParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"let add = fn(a, b) { return a + b; };",
+			"let add = fn(a, b) return (a + b);;",
+		},
+		{
+			"fn add(a, b) { return a + b; }",
+			"fn add(a, b) return (a + b);",
+		},
+		{
+			"let r = fn(x) { return x; }(5);",
+			"let r = fn(x) return x;(5);",
+		},
+		{
+			"fn(x) { return x; }(5);",
+			"fn(x) return x;(5)",
+		},
+		{
+			"let r = apply(fn(a) { return a * 2; }, 3);",
+			"let r = apply(fn(a) return (a * 2);, 3);",
+		},
+	}
+	for i, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("test [%d] expected=%q, got=%q", i, tt.expected, actual)
+		}
+	}
+}
+
+func TestExpressionStatements(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      string
+		expectedTypes []string
+	}{
+		{"5 + 5;", "(5 + 5)", []string{"*ast.ExpressionStatement"}},
+		{"add(1, 2);", "add(1, 2)", []string{"*ast.ExpressionStatement"}},
+		{"-x;", "(-x)", []string{"*ast.ExpressionStatement"}},
+		{"!true", "(!true)", []string{"*ast.ExpressionStatement"}},
+		{"(1 + 2) * 3;", "((1 + 2) * 3)", []string{"*ast.ExpressionStatement"}},
+		{"x", "x", []string{"*ast.ExpressionStatement"}},
+		{"x == 1;", "(x == 1)", []string{"*ast.ExpressionStatement"}},
+		{"x = 1;", "x = 1;", []string{"*ast.AssignmentStatement"}},
+		{"x = 1; x + 1", "x = 1;(x + 1)", []string{"*ast.AssignmentStatement", "*ast.ExpressionStatement"}},
+		{"5 5", "55", []string{"*ast.ExpressionStatement", "*ast.ExpressionStatement"}},
+	}
+	for i, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("test [%d] expected=%q, got=%q", i, tt.expected, actual)
+		}
+		if len(program.Statements) != len(tt.expectedTypes) {
+			t.Fatalf("test [%d] wrong number of statements. expected=%d, got=%d",
+				i, len(tt.expectedTypes), len(program.Statements))
+		}
+		for j, typ := range tt.expectedTypes {
+			if got := fmt.Sprintf("%T", program.Statements[j]); got != typ {
+				t.Errorf("test [%d] statement %d wrong type. expected=%s, got=%s", i, j, typ, got)
+			}
+		}
+	}
+}
+
+func TestEmptyStatements(t *testing.T) {
+	tests := []struct {
+		input      string
+		statements int
+	}{
+		{"fn g(x) { x };", 1},
+		{"while (c) { c = false; };", 1},
+		{"for x in y { x; };", 1},
+		{"if (a) { 1 } else { 2 };", 1},
+		{"x = 1;;", 1},
+		{";", 0},
+		{"while (c) { ; c = false;; }", 1},
+	}
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != tt.statements {
+			t.Errorf("wrong number of statements for %q. expected=%d, got=%d",
+				tt.input, tt.statements, len(program.Statements))
+		}
+	}
+
+	l := lexer.NewLexer("x = 1; ) y;")
+	p := NewParser(l)
+	p.ParseProgram()
+	if len(p.Errors()) != 1 || p.Errors()[0].Code != diagnostic.CodeExpectedStmt {
+		t.Errorf("a ) should still not start a statement. got=%v", p.Errors())
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `let s = "hello \"world\"\n";`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.LetStatement)
+	literal, ok := stmt.Value.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", stmt.Value)
+	}
+	if literal.Value != "hello \"world\"\n" {
+		t.Errorf("literal.Value not %q. got=%q", "hello \"world\"\n", literal.Value)
+	}
+	if program.String() != input {
+		t.Errorf("program.String() wrong. expected=%q, got=%q", input, program.String())
+	}
+}
+
+func TestStringConcatenationParsing(t *testing.T) {
+	input := `let s = "a" + b + "c";`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	expected := `let s = (("a" + b) + "c");`
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	input := "let a = 1;\nlet s = \"abc;\nlet b = 2;"
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	errors := p.Errors()
+	// the string swallowed the ; so the let statement is broken too
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 errors, got=%d %v", len(errors), errors)
+	}
+	if errors[0].Code != diagnostic.CodeUnterminatedString {
+		t.Errorf("wrong code. got=%s", errors[0].Code)
+	}
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	}
+	if _, ok := program.Statements[2].(*ast.LetStatement); !ok {
+		t.Errorf("program.Statements[2] is not ast.LetStatement. got=%T", program.Statements[2])
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+	}
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+	if array.Pos().String() != "1:1" || array.End().String() != "1:18" {
+		t.Errorf("wrong span. got=%s-%s", array.Pos(), array.End())
+	}
+}
+
+func TestParsingEmptyArrayLiteral(t *testing.T) {
+	l := lexer.NewLexer("let a = [];")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	array, ok := program.Statements[0].(*ast.LetStatement).Value.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", program.Statements[0].(*ast.LetStatement).Value)
+	}
+	if len(array.Elements) != 0 {
+		t.Errorf("len(array.Elements) not 0. got=%d", len(array.Elements))
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stmt.Expression)
+	}
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+	if !testInfixExpression(t, indexExp.Index, 1, "+", 1) {
+		return
+	}
+	if indexExp.End().String() != "1:15" {
+		t.Errorf("wrong end. got=%s", indexExp.End())
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		pairs    int
+	}{
+		{`let h = {};`, `let h = {};`, 0},
+		{`let h = {"one": 1, "two": 2, "three": 3};`, `let h = {"one": 1, "two": 2, "three": 3};`, 3},
+		{`let h = {1: true, true: "a", x: 1};`, `let h = {1: true, true: "a", x: 1};`, 3},
+		{`let h = {"one": 0 + 1, "two": 10 - 8,};`, `let h = {"one": (0 + 1), "two": (10 - 8)};`, 2},
+		{`{"a": 1}["a"]`, `({"a": 1}["a"])`, 1},
+	}
+	for i, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("test [%d] expected=%q, got=%q", i, tt.expected, actual)
+		}
+		var hash *ast.HashLiteral
+		switch stmt := program.Statements[0].(type) {
+		case *ast.LetStatement:
+			hash = stmt.Value.(*ast.HashLiteral)
+		case *ast.ExpressionStatement:
+			hash = stmt.Expression.(*ast.IndexExpression).Left.(*ast.HashLiteral)
+		}
+		if len(hash.Pairs) != tt.pairs {
+			t.Errorf("test [%d] hash.Pairs has wrong length. expected=%d, got=%d", i, tt.pairs, len(hash.Pairs))
+		}
+	}
+}
+
+func TestParsingHashLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let h = {"a" 1};`, "1:14: expected next token to be :, got INT instead"},
+		{`let h = {"a": 1 "b": 2};`, "1:17: expected next token to be ,, got STRING instead"},
+	}
+	for i, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("test [%d] expected 1 error, got=%d %v", i, len(errors), errors)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("test [%d] wrong error. expected=%q, got=%q", i, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestCommentsAreSkipped(t *testing.T) {
+	input := `let /* name */ a = 1; // one
+fn f(x /* the arg */) {
+	// body
+	return x;
+}
+a`
+	expected := "let a = 1;fn f(x) return x;a"
+	for _, opts := range [][]lexer.Option{nil, {lexer.WithComments()}} {
+		l := lexer.NewLexer(input, opts...)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != expected {
+			t.Errorf("expected=%q, got=%q", expected, program.String())
+		}
+	}
+}
+
+// a token source that is not a lexer, like a macro expander or a cached token stream
+type sliceTokenSource struct {
+	toks []tokens.Token
+}
+
+func (s *sliceTokenSource) ReadNextToken() *tokens.Token {
+	if len(s.toks) == 0 {
+		return &tokens.Token{Type: tokens.TokenTypeEOF}
+	}
+	tok := s.toks[0]
+	s.toks = s.toks[1:]
+	return &tok
+}
+
+func (s *sliceTokenSource) Errors() []*diagnostic.Diagnostic {
+	return nil
+}
+
+func TestParsingFromTokenSource(t *testing.T) {
+	source := &sliceTokenSource{toks: []tokens.Token{
+		{Type: tokens.TokenTypeLet, Literal: "let"},
+		{Type: tokens.TokenTypeIdentifier, Literal: "x"},
+		{Type: tokens.TokenTypeAssign, Literal: "="},
+		{Type: tokens.TokenTypeInt, Literal: "1"},
+		{Type: tokens.TokenTypePlus, Literal: "+"},
+		{Type: tokens.TokenTypeInt, Literal: "2"},
+		{Type: tokens.TokenTypeSemiColon, Literal: ";"},
+		{Type: tokens.TokenTypeIdentifier, Literal: "x"},
+	}}
+	p := NewParser(source)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if program.String() != "let x = (1 + 2);x" {
+		t.Errorf("wrong program. got=%q", program.String())
+	}
+}
+
+func TestParsingFromReader(t *testing.T) {
+	input := "fn add(a, b) { return a + b; }\nadd(1, 2)"
+	p := NewParser(lexer.NewLexerFromReader(strings.NewReader(input)))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if program.String() != "fn add(a, b) return (a + b);add(1, 2)" {
+		t.Errorf("wrong program. got=%q", program.String())
+	}
+}
+
+func TestNumberLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"10", int64(10)},
+		{"010", int64(10)},
+		{"1_000", int64(1000)},
+		{"0xff", int64(255)},
+		{"0o17", int64(15)},
+		{"0b1010", int64(10)},
+		{"3.14", 3.14},
+		{"1e-9", 1e-9},
+		{"1_000.5", 1000.5},
+		{"2E3", 2000.0},
+	}
+	for i, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		switch expected := tt.expected.(type) {
+		case int64:
+			literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+			if !ok || literal.Value != expected {
+				t.Errorf("test [%d] expected integer %d, got=%#v", i, expected, stmt.Expression)
+			}
+		case float64:
+			literal, ok := stmt.Expression.(*ast.FloatLiteral)
+			if !ok || literal.Value != expected {
+				t.Errorf("test [%d] expected float %g, got=%#v", i, expected, stmt.Expression)
+			}
+		}
+		if stmt.Expression.String() != tt.input {
+			t.Errorf("test [%d] literal not kept as written. got=%q", i, stmt.Expression.String())
+		}
+	}
+}
+
+func TestNumberLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775808", `1:1: could not parse "9223372036854775808" as integer`},
+		{"1e400", `1:1: could not parse "1e400" as float`},
+	}
+	for i, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0].Error() != tt.expected {
+			t.Errorf("test [%d] expected %q, got=%v", i, tt.expected, errors)
+		}
+	}
+}
+
+func TestElseIfChain(t *testing.T) {
+	input := `if (x < 0) { y = 1; } else if (x == 0) { y = 2; } else if (x < 10) { y = 3; } else { y = 4; }`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("expected 1 statement. got=%d", len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.IfStatement)
+	conditions := []string{"(x < 0)", "(x == 0)", "(x < 10)"}
+	for i, condition := range conditions {
+		if stmt.Condition.String() != condition {
+			t.Fatalf("if [%d] wrong condition. expected=%q, got=%q", i, condition, stmt.Condition.String())
+		}
+		if i == len(conditions)-1 {
+			break
+		}
+		if stmt.Alternative == nil || len(stmt.Alternative.Statements) != 1 {
+			t.Fatalf("if [%d] expected an else block holding the next if. got=%v", i, stmt.Alternative)
+		}
+		next, ok := stmt.Alternative.Statements[0].(*ast.IfStatement)
+		if !ok {
+			t.Fatalf("if [%d] alternative is not an if. got=%T", i, stmt.Alternative.Statements[0])
+		}
+		if stmt.Alternative.Pos() != next.Pos() || stmt.Alternative.End() != next.End() {
+			t.Errorf("if [%d] else block does not span the nested if", i)
+		}
+		stmt = next
+	}
+	if stmt.Alternative.String() != "y = 4;" {
+		t.Errorf("wrong final else. got=%q", stmt.Alternative.String())
+	}
+	if program.End().Offset != len(input) {
+		t.Errorf("the chain does not end at the last }. got=%s", program.End())
+	}
+}
+
+func TestIfExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = if (a) { 1 } else { 2 };", "let x = ifa 1else 2;"},
+		{"let x = if (a) { 1 } else if (b) { 2 } else { 3 };", "let x = ifa 1else ifb 2else 3;"},
+		{"let x = if (a) { 1 };", "let x = ifa 1;"},
+		{"f(if (a) { 1 } else { 2 }, 3)", "f(ifa 1else 2, 3)"},
+		{"let x = 1 + if (a) { 2 } else { 3 } * 4;", "let x = (1 + (ifa 2else 3 * 4));"},
+	}
+	for i, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("test [%d] expected=%q, got=%q", i, tt.expected, program.String())
+		}
+	}
+
+	l := lexer.NewLexer("let x = if (a) { 1 } else { 2 };")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	let := program.Statements[0].(*ast.LetStatement)
+	ifExpression, ok := let.Value.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("let value is not *ast.IfExpression. got=%T", let.Value)
+	}
+	if ifExpression.Pos().String() != "1:9" || ifExpression.End().String() != "1:32" {
+		t.Errorf("wrong span. got=%s-%s", ifExpression.Pos(), ifExpression.End())
+	}
+}
+
+func TestElseIfErrors(t *testing.T) {
+	l := lexer.NewLexer("if (a) { 1 } else if b { 2 }\nlet y = 1;")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) != 1 || errors[0].Error() != "1:22: expected next token to be (, got IDENTIFIER instead" {
+		t.Fatalf("wrong errors. got=%v", errors)
+	}
+	if program.Statements[len(program.Statements)-1].String() != "let y = 1;" {
+		t.Errorf("did not recover after the broken else if. got=%q", program.String())
+	}
+}
+
+func TestLoopStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (i < 10) { i = i + 1; }", "while(i < 10) i = (i + 1);"},
+		{"for x in [1, 2] { y = x; }", "for x in [1, 2] y = x;"},
+		{"for k in h { if (k) { break; } continue; }", "for k in h ifk break;continue;"},
+		{"while (true) { for c in \"ab\" { continue; } break; }", "whiletrue for c in \"ab\" continue;break;"},
+	}
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+		if program.End().Offset != len(tt.input) {
+			t.Errorf("loop in %q does not end at the last }. got=%s", tt.input, program.End())
+		}
+	}
+
+	l := lexer.NewLexer("for item in items { total = total + item; }")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.ForStatement. got=%T", program.Statements[0])
+	}
+	if !testIdentifier(t, stmt.Variable, "item") || !testIdentifier(t, stmt.Iterable, "items") {
+		return
+	}
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("body is not 1 statement. got=%d", len(stmt.Body.Statements))
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"break;", []string{"1:1: break is not in a loop"}},
+		{"if (a) { continue; }", []string{"1:10: continue is not in a loop"}},
+		{"while (a) { let f = fn() { break; }; }", []string{"1:28: break is not in a loop"}},
+		{"while (a) { fn g() { continue; } }", []string{"1:22: continue is not in a loop"}},
+		{"for x in y { fn() { for z in x { break; } }; break; }", nil},
+		{"while a { x; }\nlet y = 1;", []string{"1:7: expected next token to be (, got IDENTIFIER instead"}},
+		{"for 1 in y { x; }", []string{"1:5: expected next token to be IDENTIFIER, got INT instead"}},
+		{"for x y { x; }", []string{"1:7: expected next token to be IN, got IDENTIFIER instead"}},
+		{"while (a) { break }", []string{"1:19: expected next token to be ;, got } instead"}},
+	}
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != len(tt.expected) {
+			t.Errorf("wrong number of errors for %q. expected=%v, got=%v", tt.input, tt.expected, errors)
+			continue
+		}
+		for i, err := range errors {
+			if err.Error() != tt.expected[i] {
+				t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected[i], err.Error())
+			}
+		}
+	}
+}
+
+func TestRecoveryInsideBrokenLoop(t *testing.T) {
+	l := lexer.NewLexer("while a { let x = 1; break; }\nlet y = 2;")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected a single error. got=%v", p.Errors())
+	}
+	if len(program.Statements) != 2 {
+		t.Fatalf("the loop body should be skipped with its header. got=%q", program.String())
+	}
+	if _, ok := program.Statements[0].(*ast.BadStatement); !ok {
+		t.Errorf("statement 0 is not ast.BadStatement. got=%T", program.Statements[0])
+	}
+	if program.Statements[1].String() != "let y = 2;" {
+		t.Errorf("did not recover after the broken loop. got=%q", program.Statements[1].String())
+	}
+}
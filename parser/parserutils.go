@@ -1,25 +1,53 @@
 package parser
 
 import (
-	"fmt"
+	"sort"
 
+	"github.com/eyanshu1997/yacgo/ast"
 	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/diagnostic"
 	"github.com/eyanshu1997/yacgo/tokens"
 )
 
 func (p *Parser) nextToken() {
+	switch p.curToken.Type {
+	case tokens.TokenTypeLBrace:
+		p.braceDepth++
+	case tokens.TokenTypeRBrace:
+		p.braceDepth--
+	}
+	p.lastToken = p.curToken
 	p.curToken = p.peekToken
-	p.peekToken = *p.l.ReadNextToken()
+	tok := p.l.ReadNextToken()
+	// comments are only returned by a lexer made with lexer.WithComments, they are
+	// kept aside for the program's comment map and never reach the parse functions
+	for tok.Type == tokens.TokenTypeComment {
+		p.comments = append(p.comments, &ast.Comment{Token: *tok})
+		tok = p.l.ReadNextToken()
+	}
+	p.peekToken = *tok
 }
 
 func (p *Parser) peekError(t tokens.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
-		t, p.peekToken.Type)
-	log.Println(msg)
-	p.errors = append(p.errors, msg)
+	d := diagnostic.NewError(diagnostic.CodeUnexpectedToken, p.peekToken.Start, p.peekToken.End,
+		"expected next token to be %s, got %s instead", t, p.peekToken.Type)
+	d.Expected = []tokens.TokenType{t}
+	d.Found = p.peekToken.Type
+	if t == tokens.TokenTypeSemiColon {
+		d.Hint = "statements end with a ;"
+	}
+	log.Println(d.Error())
+	p.addError(d)
 }
-func (p *Parser) Errors() []string {
-	return p.errors
+
+// errors from the lexer and the parser, in source order
+func (p *Parser) Errors() []*diagnostic.Diagnostic {
+	errors := append([]*diagnostic.Diagnostic{}, p.l.Errors()...)
+	errors = append(errors, p.errors...)
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Start.Offset < errors[j].Start.Offset
+	})
+	return errors
 }
 func (p *Parser) curTokenIs(t tokens.TokenType) bool {
 	return p.curToken.Type == t
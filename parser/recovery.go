@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+// errors reported for one top level statement, the rest are dropped
+const maxErrorsPerStatement = 3
+
+// tokens that can start a statement, used to resynchronize after an error
+var statementKeywords = map[tokens.TokenType]bool{
+	tokens.TokenTypeLet:      true,
+	tokens.TokenTypeReturn:   true,
+	tokens.TokenTypeFunction: true,
+	tokens.TokenTypeIf:       true,
+	tokens.TokenTypeWhile:    true,
+	tokens.TokenTypeFor:      true,
+	tokens.TokenTypeBreak:    true,
+	tokens.TokenTypeContinue: true,
+}
+
+// once a statement has an error the parser panics: further errors are not
+// reported until it has synchronized at the end of the statement
+func (p *Parser) addError(d *diagnostic.Diagnostic) {
+	if p.panicking {
+		log.Printf("dropping error while panicking %s", d)
+		return
+	}
+	p.panicking = true
+	if d.Found == tokens.TokenTypeIllegal {
+		// the lexer already reported why the token is illegal
+		return
+	}
+	p.stmtErrors++
+	if p.stmtErrors > maxErrorsPerStatement {
+		log.Printf("dropping error over the limit %s", d)
+		return
+	}
+	p.errors = append(p.errors, d)
+}
+
+// parses the statement at curToken and leaves curToken at the first token of the next one
+// a statement with errors is replaced by an ast.BadStatement
+func (p *Parser) parseStatementWithRecovery() ast.Statement {
+	start := p.curToken
+	depth := p.braceDepth
+	stmt := p.parseStatement()
+	if !p.panicking {
+		p.nextToken()
+		return stmt
+	}
+	p.synchronize(start, depth)
+	p.panicking = false
+	log.Printf("recovered from bad statement starting at %s, now at %s", start.Start, p.curToken)
+	return &ast.BadStatement{Token: start, To: p.lastToken.End}
+}
+
+// skips to the end of the broken statement. a ; is consumed, the } closing the
+// enclosing block or a keyword starting the next statement is left for the caller.
+// a ; or keyword inside a block the statement opened, like the body of a loop
+// with a broken header, belongs to the broken statement
+func (p *Parser) synchronize(start tokens.Token, depth int) {
+	if p.curToken.Start == start.Start {
+		// always make progress
+		p.nextToken()
+	}
+	for {
+		nested := p.braceDepth > depth
+		switch {
+		case p.curTokenIs(tokens.TokenTypeEOF):
+			return
+		case nested:
+			// still inside a block opened by the broken statement
+		case p.curTokenIs(tokens.TokenTypeSemiColon):
+			p.nextToken()
+			return
+		case p.curTokenIs(tokens.TokenTypeRBrace) && p.closesBlock():
+			return
+		case statementKeywords[p.curToken.Type]:
+			return
+		}
+		p.nextToken()
+	}
+}
+
+// a } inside a broken hash literal or nested block is skipped with the rest of the statement
+func (p *Parser) closesBlock() bool {
+	return len(p.blockDepths) > 0 && p.braceDepth == p.blockDepths[len(p.blockDepths)-1]
+}
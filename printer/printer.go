@@ -0,0 +1,343 @@
+package printer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/common/utils"
+	"github.com/eyanshu1997/yacgo/parser"
+	"github.com/eyanshu1997/yacgo/tokens"
+)
+
+// binds tighter than any operator, literals and identifiers never need parentheses
+const primary = parser.INDEX + 1
+
+type printer struct {
+	out      bytes.Buffer
+	indent   int
+	comments ast.CommentMap
+}
+
+// Fprint writes the canonical source of node to w. blocks are indented with
+// tabs, every statement is on its own line and ends with a ; and expressions
+// only get the parentheses their precedence needs. the comments of a program
+// parsed with lexer.WithComments are printed with the statements they belong to
+func Fprint(w io.Writer, node ast.Node) error {
+	p := &printer{}
+	p.node(node)
+	_, err := w.Write(p.out.Bytes())
+	return err
+}
+
+// Format returns the canonical source of node
+func Format(node ast.Node) string {
+	var out bytes.Buffer
+	Fprint(&out, node)
+	return out.String()
+}
+
+func (p *printer) print(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.out.WriteByte('\n')
+	p.out.WriteString(strings.Repeat("\t", p.indent))
+}
+
+func (p *printer) node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Program:
+		p.comments = n.CommentMap
+		p.statementList(n.Statements)
+		if len(n.Statements) == 0 {
+			// no statement for the comments to belong to
+			for _, c := range n.Comments {
+				p.print(c.String() + "\n")
+			}
+		} else {
+			p.print("\n")
+		}
+	case ast.Statement:
+		p.statement(n)
+	case ast.Expression:
+		p.expression(n)
+	}
+}
+
+// statements on their own lines with their leading comments above them and the
+// trailing ones after them, a blank line in the source between two of them is kept
+func (p *printer) statementList(list []ast.Statement) {
+	lastLine := -1 // last line of what was printed so far, -1 at the start of the list
+	for _, s := range list {
+		for _, c := range p.comments.Leading(s) {
+			p.separate(lastLine, c.Pos())
+			p.print(c.String())
+			lastLine = c.End().Line
+		}
+		p.separate(lastLine, s.Pos())
+		p.statement(s)
+		lastLine = s.End().Line
+		trailing := p.comments.Trailing(s)
+		for i, c := range trailing {
+			if c.Pos().Line > lastLine {
+				// a comment at the end of a block stays on its own line
+				p.separate(lastLine, c.Pos())
+			} else if i > 0 && trailing[i-1].IsLine() {
+				p.newline()
+			} else {
+				p.print(" ")
+			}
+			p.print(c.String())
+			lastLine = c.End().Line
+		}
+	}
+}
+
+// starts the line of something at pos, keeping one blank line if the source had one after lastLine
+func (p *printer) separate(lastLine int, pos tokens.Position) {
+	if lastLine < 0 {
+		return
+	}
+	if lastLine > 0 && pos.IsValid() && pos.Line > lastLine+1 {
+		p.out.WriteByte('\n')
+	}
+	p.newline()
+}
+
+func (p *printer) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		p.print("let " + s.Name.Value + " = ")
+		p.expression(s.Value)
+		p.print(";")
+	case *ast.ReturnStatement:
+		p.print("return")
+		if s.ReturnValue != nil {
+			p.print(" ")
+			p.expression(s.ReturnValue)
+		}
+		p.print(";")
+	case *ast.AssignmentStatement:
+		p.print(s.Name.Value + " = ")
+		p.expression(s.Value)
+		p.print(";")
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression)
+		p.print(";")
+	case *ast.IfStatement:
+		p.ifElse(s.Condition, s.Consequence, s.Alternative)
+	case *ast.FunctionStatement:
+		p.print("fn " + s.Name.Value)
+		p.parameters(s.Parameters)
+		p.print(" ")
+		p.block(s.Body)
+	case *ast.WhileStatement:
+		p.print("while (")
+		p.expression(s.Condition)
+		p.print(") ")
+		p.block(s.Body)
+	case *ast.ForStatement:
+		p.print("for " + s.Variable.Value + " in ")
+		p.expression(s.Iterable)
+		p.print(" ")
+		p.block(s.Body)
+	case *ast.BlockStatement:
+		p.block(s)
+	default:
+		p.print(s.String())
+	}
+}
+
+func (p *printer) ifElse(condition ast.Expression, consequence, alternative *ast.BlockStatement) {
+	p.print("if (")
+	p.expression(condition)
+	p.print(") ")
+	p.block(consequence)
+	if alternative == nil {
+		return
+	}
+	p.print(" else ")
+	if elseIf, ok := elseIfStatement(alternative); ok {
+		p.ifElse(elseIf.Condition, elseIf.Consequence, elseIf.Alternative)
+		return
+	}
+	p.block(alternative)
+}
+
+// the if of an `else if`, the parser puts it alone in a block without braces
+func elseIfStatement(alternative *ast.BlockStatement) (*ast.IfStatement, bool) {
+	if alternative.Token.Type != tokens.TokenTypeIf || len(alternative.Statements) != 1 {
+		return nil, false
+	}
+	elseIf, ok := alternative.Statements[0].(*ast.IfStatement)
+	return elseIf, ok
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	if len(b.Statements) == 0 {
+		// an empty block only holds comments
+		p.items("{", "}", b, nil, nil)
+		return
+	}
+	p.print("{")
+	p.indent++
+	p.newline()
+	p.statementList(b.Statements)
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+// source of an item in a list
+type span struct {
+	pos, end tokens.Position
+}
+
+// the items of a literal or block between open and close, on one line unless
+// node holds comments. then every item goes on its own line, a comment on the
+// line an item ends on stays after it and the others go on their own lines
+func (p *printer) items(open, close string, node ast.Node, spans []span, item func(i int)) {
+	comments := p.comments[node]
+	if len(comments) == 0 {
+		p.print(open)
+		for i := range spans {
+			if i > 0 {
+				p.print(", ")
+			}
+			item(i)
+		}
+		p.print(close)
+		return
+	}
+	p.print(open)
+	p.indent++
+	next := 0
+	for i, s := range spans {
+		for ; next < len(comments) && comments[next].End().Offset <= s.pos.Offset; next++ {
+			p.newline()
+			p.print(comments[next].String())
+		}
+		p.newline()
+		item(i)
+		if i < len(spans)-1 {
+			p.print(",")
+		}
+		for ; next < len(comments) && comments[next].Pos().Line == s.end.Line; next++ {
+			if i < len(spans)-1 && comments[next].End().Offset > spans[i+1].pos.Offset {
+				break
+			}
+			p.print(" " + comments[next].String())
+		}
+	}
+	for ; next < len(comments); next++ {
+		p.newline()
+		p.print(comments[next].String())
+	}
+	p.indent--
+	p.newline()
+	p.print(close)
+}
+
+func (p *printer) parameters(params []*ast.Identifier) {
+	names := []string{}
+	for _, param := range params {
+		names = append(names, param.Value)
+	}
+	p.print("(" + strings.Join(names, ", ") + ")")
+}
+
+func (p *printer) expressionList(list []ast.Expression) {
+	for i, e := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.expression(e)
+	}
+}
+
+func (p *printer) expression(e ast.Expression) {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		p.print(e.Value)
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Boolean:
+		p.print(e.TokenLiteral())
+	case *ast.StringLiteral:
+		p.print(utils.QuoteString(e.Value))
+	case *ast.ArrayLiteral:
+		spans := []span{}
+		for _, el := range e.Elements {
+			spans = append(spans, span{el.Pos(), el.End()})
+		}
+		p.items("[", "]", e, spans, func(i int) {
+			p.expression(e.Elements[i])
+		})
+	case *ast.HashLiteral:
+		spans := []span{}
+		for _, pair := range e.Pairs {
+			spans = append(spans, span{pair.Key.Pos(), pair.Value.End()})
+		}
+		p.items("{", "}", e, spans, func(i int) {
+			p.expression(e.Pairs[i].Key)
+			p.print(": ")
+			p.expression(e.Pairs[i].Value)
+		})
+	case *ast.PrefixExpression:
+		p.print(e.Operator)
+		p.operand(e.Right, parser.PREFIX, false)
+	case *ast.InfixExpression:
+		precedence := parser.Precedence(e.Token.Type)
+		rightAssociative := parser.RightAssociative(e.Token.Type)
+		p.operand(e.Left, precedence, rightAssociative)
+		p.print(" " + e.Operator + " ")
+		p.operand(e.Right, precedence, !rightAssociative)
+	case *ast.CallExpression:
+		p.operand(e.Function, parser.CALL, false)
+		p.print("(")
+		p.expressionList(e.Arguments)
+		p.print(")")
+	case *ast.IndexExpression:
+		p.operand(e.Left, parser.INDEX, false)
+		p.print("[")
+		p.expression(e.Index)
+		p.print("]")
+	case *ast.IfExpression:
+		p.ifElse(e.Condition, e.Consequence, e.Alternative)
+	case *ast.FunctionLiteral:
+		p.print("fn")
+		p.parameters(e.Parameters)
+		p.print(" ")
+		p.block(e.Body)
+	default:
+		p.print(e.String())
+	}
+}
+
+// an operand with the same precedence as its parent needs parentheses too when
+// it is on the side the operator does not group to, the right for most of them
+func (p *printer) operand(e ast.Expression, parent int, sameNeedsParens bool) {
+	precedence := precedenceOf(e)
+	if precedence < parent || sameNeedsParens && precedence == parent {
+		p.print("(")
+		p.expression(e)
+		p.print(")")
+		return
+	}
+	p.expression(e)
+}
+
+func precedenceOf(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(e.Token.Type)
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.IfExpression:
+		// an operand starting with if would be read as an if statement at the start of a line
+		return parser.LOWEST
+	default:
+		return primary
+	}
+}
@@ -0,0 +1,183 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/parser"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	l := lexer.NewLexer(input, lexer.WithComments())
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+	for _, msg := range p.Errors() {
+		t.Fatalf("parser error for %q: %q", input, msg)
+	}
+	return program
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=1;", "let x = 1;\n"},
+		{"return x;", "return x;\n"},
+		{"x=5*2;x", "x = 5 * 2;\nx;\n"},
+		{"let a = (1 + 2) * 3;", "let a = (1 + 2) * 3;\n"},
+		{"let a = 1 + (2 * 3);", "let a = 1 + 2 * 3;\n"},
+		{"a - (b - c)", "a - (b - c);\n"},
+		{"(a - b) - c", "a - b - c;\n"},
+		{"-(a + b)", "-(a + b);\n"},
+		{"!(-a)", "!-a;\n"},
+		{"(a == b) == c", "a == b == c;\n"},
+		{"2 ** (3 ** 2)", "2 ** 3 ** 2;\n"},
+		{"(2 ** 3) ** 2", "(2 ** 3) ** 2;\n"},
+		{"(-2) ** 2", "(-2) ** 2;\n"},
+		{"-(2 ** 2)", "-2 ** 2;\n"},
+		{"(a | b) & ~(c ^ d)", "(a | b) & ~(c ^ d);\n"},
+		{"(a + b)(c)", "(a + b)(c);\n"},
+		{"(-a)[0]", "(-a)[0];\n"},
+		{"a[0][1](2)", "a[0][1](2);\n"},
+		{`"a\tb\n\"c\""`, `"a\tb\n\"c\"";` + "\n"},
+		{`[1,"two",true]`, `[1, "two", true];` + "\n"},
+		{`{"a":1,2:[3],}`, `{"a": 1, 2: [3]};` + "\n"},
+		{"fn add(a,b){return a+b;}", "fn add(a, b) {\n\treturn a + b;\n}\n"},
+		{"let f = fn(){};", "let f = fn() {};\n"},
+		{
+			"if(x<1){y=1;}else{if(x>2){y=2;}}",
+			"if (x < 1) {\n\ty = 1;\n} else {\n\tif (x > 2) {\n\t\ty = 2;\n\t}\n}\n",
+		},
+		{
+			"if(a){x;}else if(b){y;}else{z;}",
+			"if (a) {\n\tx;\n} else if (b) {\n\ty;\n} else {\n\tz;\n}\n",
+		},
+		{
+			"let v = if(a){1}else{2};",
+			"let v = if (a) {\n\t1;\n} else {\n\t2;\n};\n",
+		},
+		{"f(if (a) {}) + 1", "f(if (a) {}) + 1;\n"},
+		{"(if (a) {f} else {g})(1)", "(if (a) {\n\tf;\n} else {\n\tg;\n})(1);\n"},
+		{"while(i<3){i=i+1;continue;}", "while (i < 3) {\n\ti = i + 1;\n\tcontinue;\n}\n"},
+		{"for x in [1,2]{if(x){break;}}", "for x in [1, 2] {\n\tif (x) {\n\t\tbreak;\n\t}\n}\n"},
+		{"let a = 1;\n\n\n\nlet b = 2; let c = 3;", "let a = 1;\n\nlet b = 2;\nlet c = 3;\n"},
+		{"", ""},
+	}
+	for i, tt := range tests {
+		got := Format(parseProgram(t, tt.input))
+		if got != tt.expected {
+			t.Errorf("test [%d] wrong output.\nexpected=%q\ngot=     %q", i, tt.expected, got)
+		}
+	}
+}
+
+// formatting must not change what the program means and a formatted program is already canonical
+func TestFormatRoundTrip(t *testing.T) {
+	inputs := []string{
+		"let x = 5 * (2 + -3) / f(a, b)[1 - 1];",
+		"let s = \"one\\ttwo\" + \"\\u{1F600}\"; s",
+		"fn fib(n) { if (n < 2) { return n; } return fib(n - 1) + fib(n - 2); } fib(10)",
+		"let apply = fn(f, x) { f(x) }; apply(fn(y) { y * 2 }, 3)",
+		"let h = {\"k\": [1, 2, {true: !false}]}; h[\"k\"][2][true]",
+		"a = 1; a = a - (a - (a - 1)); -(-a); !(!true)",
+		"if (a != b == (c < d)) { x } else { if (y) { z } }",
+		"fn(a) { a }(1)(2)",
+		"if (a) { 1 } else if (b) { if (c) { 2 } else if (d) { 3 } } else { 4 }",
+		"let v = 1 + if (a) { 2 } else if (b) { 3 } * 4; -if (c) { 5 } else { 6 }",
+		"let n = 0xff + 0b1010 - 1_000 * 3.14e-2 / -2.5;",
+		"let m = 2 ** 3 ** -(1 ** 2) % 7 << 1 | ~x & y ^ (z >> 2);",
+		"let i = 0; while (i < 10) { i = i + 1; if (i % 2 == 0) { continue; } for c in \"ab\" { break; } }",
+		"// header\n\nlet a = 1; // one\n/* two */ let b = 2;\n// end",
+		"fn f(x) {\n  // first\n  let y = x; /* t */ // t2\n\n\n  return y;\n  // last\n}",
+		"while (a) {\n  a = a - 1; // down\n  /* next */\n  if (a) { break; } // out\n}",
+		"let h = {\n  \"a\": 1, // first\n  \"b\": 2\n};\nh",
+		"let a = [ // numbers\n  1,\n  2 /* two */, 3\n  // no more\n];",
+		"if (a) { // note\n} else { /* nothing */ }\nfn f() {\n  // todo\n}",
+		"f([1, {\"k\": 2 /* v */}], fn() { /* empty */ })",
+	}
+	for _, input := range inputs {
+		program := parseProgram(t, input)
+		formatted := Format(program)
+		reparsed := parseProgram(t, formatted)
+		if reparsed.String() != program.String() {
+			t.Errorf("formatting changed the program %q.\nexpected=%q\ngot=     %q", input, program.String(), reparsed.String())
+		}
+		if commentText(reparsed) != commentText(program) {
+			t.Errorf("formatting changed the comments of %q.\nexpected=%q\ngot=     %q", input, commentText(program), commentText(reparsed))
+		}
+		if again := Format(reparsed); again != formatted {
+			t.Errorf("format is not idempotent for %q.\nfirst= %q\nsecond=%q", input, formatted, again)
+		}
+	}
+}
+
+func commentText(program *ast.Program) string {
+	var text []string
+	for _, c := range program.Comments {
+		text = append(text, c.String())
+	}
+	return strings.Join(text, "|")
+}
+
+func TestFormatComments(t *testing.T) {
+	input := `// header
+
+let a=1; // one
+/* block */ let b=2;
+fn f(x){
+    // inside
+    let y=x;   /* t */ // t2
+
+
+    return y;
+    // end of body
+}
+if (a) { /* empty */ }
+let h = {
+  "a": [1, 2], // first
+  /* before b */ "b": [
+    3 // three
+  ]
+};`
+	expected := `// header
+
+let a = 1; // one
+/* block */
+let b = 2;
+fn f(x) {
+	// inside
+	let y = x; /* t */ // t2
+
+	return y;
+	// end of body
+}
+if (a) {
+	/* empty */
+}
+let h = {
+	"a": [1, 2], // first
+	/* before b */
+	"b": [
+		3 // three
+	]
+};
+`
+	l := lexer.NewLexer(input, lexer.WithComments())
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	got := Format(program)
+	if got != expected {
+		t.Fatalf("wrong output.\nexpected=%q\ngot=     %q", expected, got)
+	}
+	// the comments stay where they are on the next run
+	l = lexer.NewLexer(got, lexer.WithComments())
+	if again := Format(parser.NewParser(l).ParseProgram()); again != got {
+		t.Errorf("format is not idempotent.\nfirst= %q\nsecond=%q", got, again)
+	}
+}
@@ -5,20 +5,24 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/evaluator"
 	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/object"
 	"github.com/eyanshu1997/yacgo/parser"
+	"github.com/eyanshu1997/yacgo/resolver"
 )
 
 const PROMPT = ">> "
 
-func printParserErrors(out io.Writer, errors []string) {
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
-	}
+func printParserErrors(out io.Writer, errors []*diagnostic.Diagnostic, source string) {
+	io.WriteString(out, diagnostic.RenderAll(errors, source))
 }
 
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
+	env := object.NewEnvironment()
+	r := resolver.NewResolver()
 	for {
 		fmt.Printf(PROMPT)
 		scanned := scanner.Scan()
@@ -30,10 +34,17 @@ func Start(in io.Reader, out io.Writer) {
 		p := parser.NewParser(l)
 		program := p.ParseProgram()
 		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+			printParserErrors(out, p.Errors(), line)
+			continue
+		}
+		if errors := r.Resolve(program); len(errors) != 0 {
+			printParserErrors(out, errors, line)
 			continue
 		}
-		io.WriteString(out, program.String())
-		io.WriteString(out, "\n")
+		evaluated := evaluator.Eval(program, env)
+		if evaluated != nil {
+			io.WriteString(out, evaluated.Inspect())
+			io.WriteString(out, "\n")
+		}
 	}
 }
@@ -0,0 +1,256 @@
+package resolver
+
+import (
+	"sort"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/common/log"
+	"github.com/eyanshu1997/yacgo/diagnostic"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+// the names declared directly in a program, a function or a block
+type scope struct {
+	slots map[string]int    // slot of each name, in order of declaration
+	decls []*ast.Identifier // declaration of each slot, nil for one made by an earlier Resolve
+}
+
+func newScope() *scope {
+	return &scope{slots: make(map[string]int)}
+}
+
+// forgets the names declared after the first n
+func (s *scope) rollback(n int) {
+	for name, slot := range s.slots {
+		if slot >= n {
+			delete(s.slots, name)
+		}
+	}
+	s.decls = s.decls[:n]
+}
+
+// a function body waits until the statements around it are resolved, so it can
+// use names declared after it like itself or a function declared below it
+type pendingFunction struct {
+	scopes     []*scope // the scopes around the function
+	parameters []*ast.Identifier
+	body       *ast.BlockStatement
+}
+
+// Resolver checks that every name is declared and fills in the Binding of each
+// identifier. the global scope is kept from one Resolve to the next so the repl
+// can use what earlier lines declared
+type Resolver struct {
+	global  *scope
+	scopes  []*scope // innermost last
+	outer   int      // scopes from before the function being resolved, its own start after them
+	pending []pendingFunction
+	errors  []*diagnostic.Diagnostic
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{global: newScope()}
+}
+
+// Resolve resolves a whole program, convenience for a single use resolver
+func Resolve(program *ast.Program) []*diagnostic.Diagnostic {
+	return NewResolver().Resolve(program)
+}
+
+// Resolve annotates the identifiers of program and returns the problems found:
+// undefined names, names declared twice in one scope and assignments to names
+// that were never declared or are builtins. a global of an earlier program can be declared again
+func (r *Resolver) Resolve(program *ast.Program) []*diagnostic.Diagnostic {
+	r.errors = nil
+	for i := range r.global.decls {
+		r.global.decls[i] = nil
+	}
+	globals := len(r.global.decls)
+	r.scopes = []*scope{r.global}
+	r.outer = 0
+	r.statements(program.Statements)
+	for len(r.pending) > 0 {
+		fn := r.pending[0]
+		r.pending = r.pending[1:]
+		r.outer = len(fn.scopes)
+		r.scopes = append(fn.scopes, newScope())
+		for _, param := range fn.parameters {
+			r.declare(param)
+		}
+		r.statements(fn.body.Statements)
+	}
+	r.scopes = nil
+	// function bodies are resolved last, put their errors back in source order
+	sort.SliceStable(r.errors, func(i, j int) bool {
+		return r.errors[i].Start.Offset < r.errors[j].Start.Offset
+	})
+	if len(r.errors) > 0 {
+		// the program will not run, so what it declared does not exist
+		r.global.rollback(globals)
+	}
+	return r.errors
+}
+
+func (r *Resolver) statements(list []ast.Statement) {
+	for _, s := range list {
+		r.statement(s)
+	}
+}
+
+func (r *Resolver) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		// the name is not visible in its own value, except in function bodies
+		r.expression(s.Value)
+		r.declare(s.Name)
+	case *ast.AssignmentStatement:
+		r.expression(s.Value)
+		if !r.lookup(s.Name) {
+			r.addError(diagnostic.CodeUndeclaredAssign, s.Name, "assignment to undeclared identifier: %s", s.Name.Value)
+		} else if s.Name.Binding.IsBuiltin() {
+			d := r.addError(diagnostic.CodeBuiltinAssign, s.Name, "cannot assign to the builtin %s", s.Name.Value)
+			d.Hint = "declare it with let to hide the builtin"
+		}
+	case *ast.ReturnStatement:
+		r.expression(s.ReturnValue)
+	case *ast.ExpressionStatement:
+		r.expression(s.Expression)
+	case *ast.IfStatement:
+		r.conditional(s.Condition, s.Consequence, s.Alternative)
+	case *ast.FunctionStatement:
+		r.declare(s.Name)
+		r.function(s.Parameters, s.Body)
+	case *ast.WhileStatement:
+		r.expression(s.Condition)
+		r.block(s.Body)
+	case *ast.ForStatement:
+		r.expression(s.Iterable)
+		r.block(s.Body, s.Variable)
+	case *ast.BlockStatement:
+		r.block(s)
+	case *ast.BreakStatement, *ast.ContinueStatement, *ast.BadStatement, nil:
+		// nothing to resolve
+	default:
+		log.Printf("resolver: unexpected statement %T", s)
+	}
+}
+
+func (r *Resolver) expression(e ast.Expression) {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		if !r.lookup(e) {
+			r.addError(diagnostic.CodeUndefined, e, "undefined identifier: %s", e.Value)
+		}
+	case *ast.PrefixExpression:
+		r.expression(e.Right)
+	case *ast.InfixExpression:
+		r.expression(e.Left)
+		r.expression(e.Right)
+	case *ast.CallExpression:
+		r.expression(e.Function)
+		r.expressions(e.Arguments)
+	case *ast.IndexExpression:
+		r.expression(e.Left)
+		r.expression(e.Index)
+	case *ast.ArrayLiteral:
+		r.expressions(e.Elements)
+	case *ast.HashLiteral:
+		for _, pair := range e.Pairs {
+			r.expression(pair.Key)
+			r.expression(pair.Value)
+		}
+	case *ast.IfExpression:
+		r.conditional(e.Condition, e.Consequence, e.Alternative)
+	case *ast.FunctionLiteral:
+		r.function(e.Parameters, e.Body)
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean, *ast.BadExpression, nil:
+		// nothing to resolve
+	default:
+		log.Printf("resolver: unexpected expression %T", e)
+	}
+}
+
+func (r *Resolver) expressions(list []ast.Expression) {
+	for _, e := range list {
+		r.expression(e)
+	}
+}
+
+func (r *Resolver) conditional(condition ast.Expression, consequence, alternative *ast.BlockStatement) {
+	r.expression(condition)
+	r.block(consequence)
+	if alternative != nil {
+		r.block(alternative)
+	}
+}
+
+// a block is a scope of its own, declared are the names bound before its statements like a for variable
+func (r *Resolver) block(b *ast.BlockStatement, declared ...*ast.Identifier) {
+	if b == nil {
+		return
+	}
+	r.scopes = append(r.scopes, newScope())
+	for _, ident := range declared {
+		r.declare(ident)
+	}
+	r.statements(b.Statements)
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+// the parameters and the body of a function share one scope
+func (r *Resolver) function(parameters []*ast.Identifier, body *ast.BlockStatement) {
+	if body == nil {
+		return
+	}
+	scopes := make([]*scope, len(r.scopes))
+	copy(scopes, r.scopes)
+	r.pending = append(r.pending, pendingFunction{scopes: scopes, parameters: parameters, body: body})
+}
+
+func (r *Resolver) declare(ident *ast.Identifier) {
+	current := r.scopes[len(r.scopes)-1]
+	if slot, ok := current.slots[ident.Value]; ok {
+		ident.Binding = &ast.Binding{Depth: 0, Slot: slot}
+		if previous := current.decls[slot]; previous != nil {
+			d := r.addError(diagnostic.CodeDuplicateDecl, ident, "%s is already declared in this scope", ident.Value)
+			d.Hint = "previous declaration at " + previous.Pos().String()
+			return
+		}
+		current.decls[slot] = ident
+		return
+	}
+	slot := len(current.decls)
+	current.slots[ident.Value] = slot
+	current.decls = append(current.decls, ident)
+	ident.Binding = &ast.Binding{Depth: 0, Slot: slot}
+}
+
+// sets the binding of ident to the nearest declaration of its name, false if there is none
+func (r *Resolver) lookup(ident *ast.Identifier) bool {
+	for depth := 0; depth < len(r.scopes); depth++ {
+		index := len(r.scopes) - 1 - depth
+		if slot, ok := r.scopes[index].slots[ident.Value]; ok {
+			ident.Binding = &ast.Binding{Depth: depth, Slot: slot}
+			if index > 0 && index < r.outer {
+				// a local of an enclosing function or of a block around it, globals are never captured
+				r.scopes[index].decls[slot].Binding.Captured = true
+			}
+			return true
+		}
+	}
+	for i, b := range object.Builtins {
+		if b.Name == ident.Value {
+			ident.Binding = &ast.Binding{Depth: -1, Slot: i}
+			return true
+		}
+	}
+	ident.Binding = nil
+	return false
+}
+
+func (r *Resolver) addError(code diagnostic.Code, ident *ast.Identifier, format string, args ...interface{}) *diagnostic.Diagnostic {
+	d := diagnostic.NewError(code, ident.Pos(), ident.End(), format, args...)
+	log.Println(d.Error())
+	r.errors = append(r.errors, d)
+	return d
+}
@@ -0,0 +1,153 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/ast"
+	"github.com/eyanshu1997/yacgo/lexer"
+	"github.com/eyanshu1997/yacgo/parser"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	p := parser.NewParser(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	for _, msg := range p.Errors() {
+		t.Fatalf("parser error for %q: %q", input, msg)
+	}
+	return program
+}
+
+// name@depth:slot for every identifier in source order, name? for unresolved ones
+func bindings(program *ast.Program) string {
+	var out []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok {
+			if ident.Binding == nil {
+				out = append(out, ident.Value+"?")
+			} else {
+				out = append(out, fmt.Sprintf("%s@%d:%d", ident.Value, ident.Binding.Depth, ident.Binding.Slot))
+			}
+		}
+		return true
+	})
+	return strings.Join(out, " ")
+}
+
+func TestBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = 1; let b = a;", "a@0:0 b@0:1 a@0:0"},
+		{"let a = 1; a = a + 1;", "a@0:0 a@0:0 a@0:0"},
+		{"let f = fn(x, y) { x + y };", "f@0:0 x@0:0 y@0:1 x@0:0 y@0:1"},
+		{"let a = 1; let f = fn(x) { let b = x; a + b };", "a@0:0 f@0:1 x@0:0 b@0:1 x@0:0 a@1:0 b@0:1"},
+		{"let a = 1; if (a) { let b = a; b } else { a }", "a@0:0 a@0:0 b@0:0 a@1:0 b@0:0 a@1:0"},
+		{"let xs = [1]; for x in xs { let y = x; }", "xs@0:0 x@0:0 xs@0:0 y@0:1 x@0:0"},
+		{"let i = 0; while (i < 3) { i = i + 1; }", "i@0:0 i@0:0 i@1:0 i@1:0"},
+		{"fn fact(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }", "fact@0:0 n@0:0 n@0:0 n@1:0 fact@2:0 n@1:0"},
+		{"let add = fn(a) { fn(b) { a + b } };", "add@0:0 a@0:0 b@0:0 a@1:0 b@0:0"},
+		{"let f = fn() { g() }; let g = fn() { 1 };", "f@0:0 g@1:1 g@0:1"},
+		{"len([push([], 1)])", "len@-1:0 push@-1:1"},
+		{"let len = fn(x) { 0 }; len(1)", "len@0:0 x@0:0 len@0:0"},
+		{"let a = 1; let f = fn(a) { a };", "a@0:0 f@0:1 a@0:0 a@0:0"},
+		{"let h = {\"k\": 1}; let k = 2; {k: h}", "h@0:0 k@0:1 k@0:1 h@0:0"},
+		{"if (true) { 1 } else if (false) { let c = 2; c }", "c@0:0 c@0:0"},
+	}
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		if errors := Resolve(program); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, errors)
+			continue
+		}
+		if got := bindings(program); got != tt.expected {
+			t.Errorf("wrong bindings for %q.\nexpected=%q\ngot=     %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"x", []string{"1:1: undefined identifier: x"}},
+		{"let a = a;", []string{"1:9: undefined identifier: a"}},
+		{"let a = 1; let a = 2;", []string{"1:16: a is already declared in this scope"}},
+		{"fn f(a, a) { a }", []string{"1:9: a is already declared in this scope"}},
+		{"let f = fn(a) { let a = 1; };", []string{"1:21: a is already declared in this scope"}},
+		{"for x in [1] { let x = 2; }", []string{"1:20: x is already declared in this scope"}},
+		{"let a = 1; if (a) { let a = 2; }", nil},
+		{"y = 1;", []string{"1:1: assignment to undeclared identifier: y"}},
+		{"let f = fn() { z = 1; };", []string{"1:16: assignment to undeclared identifier: z"}},
+		{"len = 1;", []string{"1:1: cannot assign to the builtin len"}},
+		{"fn f() { g }\nlet x = y;\nfn h() { let a = 1; let a = 2; }", []string{
+			"1:10: undefined identifier: g",
+			"2:9: undefined identifier: y",
+			"3:25: a is already declared in this scope",
+		}},
+		{"fn f() { puts = 1; }", []string{"1:10: cannot assign to the builtin puts"}},
+		{"let len = 1; len = 2;", nil},
+		{"if (true) { let b = 1; } b", []string{"1:26: undefined identifier: b"}},
+		{"for x in [1] { } x", []string{"1:18: undefined identifier: x"}},
+		{"let f = fn() { let inner = 1; }; inner", []string{"1:34: undefined identifier: inner"}},
+		{"a + fn(b) { b + c }(d)", []string{
+			"1:1: undefined identifier: a",
+			"1:17: undefined identifier: c",
+			"1:21: undefined identifier: d",
+		}},
+	}
+	for _, tt := range tests {
+		errors := Resolve(parseProgram(t, tt.input))
+		if len(errors) != len(tt.expected) {
+			t.Errorf("wrong number of errors for %q. expected=%v, got=%v", tt.input, tt.expected, errors)
+			continue
+		}
+		for i, err := range errors {
+			if err.Error() != tt.expected[i] {
+				t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected[i], err.Error())
+			}
+		}
+	}
+}
+
+func TestDuplicateHint(t *testing.T) {
+	errors := Resolve(parseProgram(t, "let a = 1;\nlet a = 2;"))
+	if len(errors) != 1 || errors[0].Hint != "previous declaration at 1:5" {
+		t.Fatalf("wrong errors. got=%v", errors)
+	}
+	if errors[0].Start.Line != 2 || errors[0].End.Column-errors[0].Start.Column != 1 {
+		t.Errorf("wrong span. got=%s-%s", errors[0].Start, errors[0].End)
+	}
+}
+
+// the repl resolves each line with the same resolver
+func TestGlobalsAcrossPrograms(t *testing.T) {
+	r := NewResolver()
+	steps := []struct {
+		input  string
+		errors int
+	}{
+		{"let a = 1;", 0},
+		{"let b = a + 1;", 0},
+		{"let a = 3;", 0},
+		{"let c = 1; let c = 2;", 1},
+		{"c", 1},
+		{"let d = undefined;", 1},
+		{"d", 1},
+		{"let e = b;", 0},
+	}
+	for _, step := range steps {
+		program := parseProgram(t, step.input)
+		if errors := r.Resolve(program); len(errors) != step.errors {
+			t.Errorf("wrong errors for %q. expected %d, got=%v", step.input, step.errors, errors)
+		}
+	}
+	program := parseProgram(t, "a + b + e")
+	r.Resolve(program)
+	if got := bindings(program); got != "a@0:0 b@0:1 e@0:2" {
+		t.Errorf("wrong global slots. got=%q", got)
+	}
+}
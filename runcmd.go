@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/eyanshu1997/yacgo/objfile"
+	"github.com/eyanshu1997/yacgo/vm"
+)
+
+// runRun loads an object file and runs it on the vm, returning the exit code
+func runRun(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: yacgo run prog.ybc\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	name := flags.Arg(0)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run: %s\n", err)
+		return 1
+	}
+	bytecode, err := objfile.Decode(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run: %s: %s\n", name, err)
+		return 1
+	}
+	machine := vm.New(bytecode)
+	if err := machine.Run(); err != nil {
+		if position, ok := machine.Position(); ok {
+			fmt.Fprintf(os.Stderr, "%s: runtime error at %d:%d: %s\n", name, position.Line, position.Column, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: runtime error: %s\n", name, err)
+		}
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,6 @@
+package tokens
+
+const (
+	// Literal is the whole comment including the // or /* */ markers
+	TokenTypeComment TokenType = "COMMENT"
+)
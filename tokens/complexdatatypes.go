@@ -1 +1,6 @@
 package tokens
+
+const (
+	// Literal is the decoded value, without the quotes and with escapes resolved
+	TokenTypeString TokenType = "STRING"
+)
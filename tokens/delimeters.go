@@ -4,8 +4,11 @@ const (
 	// Delimiters
 	TokenTypeComma     TokenType = ","
 	TokenTypeSemiColon TokenType = ";"
+	TokenTypeColon     TokenType = ":"
 	TokenTypeLParen    TokenType = "("
 	TokenTypeRParen    TokenType = ")"
 	TokenTypeLBrace    TokenType = "{"
 	TokenTypeRBrace    TokenType = "}"
+	TokenTypeLBracket  TokenType = "["
+	TokenTypeRBracket  TokenType = "]"
 )
@@ -9,16 +9,26 @@ const (
 	TokenTypeIf       TokenType = "IF"
 	TokenTypeElse     TokenType = "ELSE"
 	TokenTypeReturn   TokenType = "RETURN"
+	TokenTypeWhile    TokenType = "WHILE"
+	TokenTypeFor      TokenType = "FOR"
+	TokenTypeIn       TokenType = "IN"
+	TokenTypeBreak    TokenType = "BREAK"
+	TokenTypeContinue TokenType = "CONTINUE"
 )
 
 var keywordsMap = map[string]TokenType{
-	"fn":     TokenTypeFunction,
-	"let":    TokenTypeLet,
-	"true":   TokenTypeTrue,
-	"false":  TokenTypeFalse,
-	"if":     TokenTypeIf,
-	"else":   TokenTypeElse,
-	"return": TokenTypeReturn,
+	"fn":       TokenTypeFunction,
+	"let":      TokenTypeLet,
+	"true":     TokenTypeTrue,
+	"false":    TokenTypeFalse,
+	"if":       TokenTypeIf,
+	"else":     TokenTypeElse,
+	"return":   TokenTypeReturn,
+	"while":    TokenTypeWhile,
+	"for":      TokenTypeFor,
+	"in":       TokenTypeIn,
+	"break":    TokenTypeBreak,
+	"continue": TokenTypeContinue,
 }
 
 func CheckIfKeywordType(literal string) TokenType {
@@ -11,8 +11,22 @@ const (
 	TokenTypeSubtract TokenType = "-"
 	TokenTypeAstrisk  TokenType = "*"
 	TokenTypeDivide   TokenType = "/"
+	TokenTypeModulo   TokenType = "%"
+
+	// bitwise operators
+	TokenTypeBitAnd TokenType = "&"
+	TokenTypeBitOr  TokenType = "|"
+	TokenTypeBitXor TokenType = "^"
+	TokenTypeBitNot TokenType = "~"
 
 	//MultiToken operators
 	TokenTypeEQ    TokenType = "=="
 	TokenTypeNotEQ TokenType = "!="
+	TokenTypeLTE   TokenType = "<="
+	TokenTypeGTE   TokenType = ">="
+	TokenTypeAnd   TokenType = "&&"
+	TokenTypeOr    TokenType = "||"
+	TokenTypePower TokenType = "**"
+	TokenTypeShl   TokenType = "<<"
+	TokenTypeShr   TokenType = ">>"
 )
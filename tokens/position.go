@@ -0,0 +1,28 @@
+package tokens
+
+import "fmt"
+
+// Position is a point in the source, Line and Column start at 1
+type Position struct {
+	File   string
+	Line   int
+	Column int
+	Offset int // byte offset from the start of the source
+}
+
+// the zero Position is used for nodes that were not produced by the parser
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// file:line:col, or line:col when there is no file name
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.File != "" {
+			return p.File
+		}
+		return "-"
+	}
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
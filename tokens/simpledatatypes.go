@@ -3,3 +3,8 @@ package tokens
 const (
 	TokenTypeInt TokenType = "INT"
 )
+
+const (
+	// Literal is the source text, prefixes and _ separators included
+	TokenTypeFloat TokenType = "FLOAT"
+)
@@ -5,26 +5,44 @@ const (
 	TokenTypeEOF     TokenType = "EOF"
 )
 
-var (
-	multiToken = []byte{'=', '!'}
-)
+// operators made of two characters, the lexer tries them before the single character ones
+var multiTokens = map[string]TokenType{
+	"==": TokenTypeEQ,
+	"!=": TokenTypeNotEQ,
+	"<=": TokenTypeLTE,
+	">=": TokenTypeGTE,
+	"&&": TokenTypeAnd,
+	"||": TokenTypeOr,
+	"**": TokenTypePower,
+	"<<": TokenTypeShl,
+	">>": TokenTypeShr,
+}
 
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Start   Position // first character of the token
+	End     Position // just after the last character of the token
 }
 
-func NewToken(tokenType TokenType, literal byte) *Token {
+func NewToken(tokenType TokenType, literal rune) *Token {
 	return &Token{Type: tokenType, Literal: string(literal)}
 }
 
-func CanHaveNextToken(ch byte) bool {
-	for _, b := range multiToken {
-		if b == ch {
+// ch is the first character of a multi character operator
+func CanHaveNextToken(ch rune) bool {
+	for literal := range multiTokens {
+		if []rune(literal)[0] == ch {
 			return true
 		}
 	}
 	return false
 }
+
+// the type of the multi character operator literal, if it is one
+func LookupMultiToken(literal string) (TokenType, bool) {
+	tokenType, ok := multiTokens[literal]
+	return tokenType, ok
+}
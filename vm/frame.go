@@ -0,0 +1,30 @@
+package vm
+
+import (
+	"github.com/eyanshu1997/yacgo/code"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+// a call of a closure, its locals start at basePointer on the stack
+type Frame struct {
+	cl          *object.Closure
+	ip          int
+	basePointer int
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, basePointer: basePointer}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
+
+// the state of a for loop, it sits on the stack below the loop's values
+type iterator struct {
+	elements []object.Object
+	next     int
+}
+
+func (it *iterator) Type() object.ObjectType { return "ITERATOR" }
+func (it *iterator) Inspect() string         { return "iterator" }
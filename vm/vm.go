@@ -0,0 +1,373 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eyanshu1997/yacgo/code"
+	"github.com/eyanshu1997/yacgo/compiler"
+	"github.com/eyanshu1997/yacgo/evaluator"
+	"github.com/eyanshu1997/yacgo/object"
+)
+
+const (
+	StackSize    = 2048    // values the stack starts with, it grows as the calls get deeper
+	MaxStackSize = 1 << 20 // values the stack can grow to
+	GlobalsSize  = 1 << 16
+	MaxFrames    = 1024
+)
+
+var operators = map[code.Opcode]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpMod:          "%",
+	code.OpPow:          "**",
+	code.OpBitAnd:       "&",
+	code.OpBitOr:        "|",
+	code.OpBitXor:       "^",
+	code.OpShl:          "<<",
+	code.OpShr:          ">>",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpLessThan:     "<",
+	code.OpLessEqual:    "<=",
+	code.OpGreaterThan:  ">",
+	code.OpGreaterEqual: ">=",
+	code.OpMinus:        "-",
+	code.OpBang:         "!",
+	code.OpBitNot:       "~",
+}
+
+// VM runs bytecode. the operators, indexing and iteration are the evaluator's
+// ones, so a program gives the same values and the same errors either way
+type VM struct {
+	constants []object.Object
+	globals   []object.Object
+	stack     []object.Object
+	sp        int // the next free slot, the top of the stack is stack[sp-1]
+	frames    []*Frame
+	result    object.Object
+	done      bool
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithGlobalsState(bytecode, make([]object.Object, GlobalsSize))
+}
+
+// NewWithGlobalsState runs bytecode with the globals of an earlier vm, the repl
+// keeps them from one line to the next
+func NewWithGlobalsState(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	main := &object.CompiledFunction{Instructions: bytecode.Instructions, NumLocals: bytecode.NumLocals, Lines: bytecode.Lines}
+	mainFrame := NewFrame(&object.Closure{Fn: main}, 0)
+	return &VM{
+		constants: bytecode.Constants,
+		globals:   globals,
+		stack:     make([]object.Object, StackSize),
+		sp:        main.NumLocals,
+		frames:    []*Frame{mainFrame},
+	}
+}
+
+// the value of the program once it has run, nil when its last statement has none
+func (vm *VM) Result() object.Object {
+	return vm.result
+}
+
+// Position is where in the source the instruction being run comes from, after
+// Run returned an error the one that failed. false when the bytecode has no
+// line table for it
+func (vm *VM) Position() (code.LineEntry, bool) {
+	frame := vm.currentFrame()
+	if frame.ip == 0 {
+		return code.LineEntry{}, false
+	}
+	return frame.cl.Fn.Lines.Lookup(frame.ip - 1)
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[len(vm.frames)-1]
+}
+
+func (vm *VM) push(obj object.Object) error {
+	if err := vm.grow(vm.sp + 1); err != nil {
+		return err
+	}
+	vm.stack[vm.sp] = obj
+	vm.sp++
+	return nil
+}
+
+// makes the stack hold at least size values. the frames only keep offsets into
+// it, so it can move
+func (vm *VM) grow(size int) error {
+	if size <= len(vm.stack) {
+		return nil
+	}
+	if size > MaxStackSize {
+		return errors.New("stack overflow")
+	}
+	n := 2 * len(vm.stack)
+	for n < size {
+		n *= 2
+	}
+	if n > MaxStackSize {
+		n = MaxStackSize
+	}
+	stack := make([]object.Object, n)
+	copy(stack, vm.stack[:vm.sp])
+	vm.stack = stack
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	obj := vm.stack[vm.sp-1]
+	vm.sp--
+	return obj
+}
+
+// pushes the result of an operation, an error object stops the program
+func (vm *VM) pushResult(obj object.Object) error {
+	if errObj, ok := obj.(*object.Error); ok {
+		return errors.New(errObj.Message)
+	}
+	return vm.push(obj)
+}
+
+// Run executes the program, a runtime error stops it and is returned with the
+// message the evaluator would give for it. the bytecode has to come from the
+// compiler or objfile.Decode, which rejects code the vm cannot run
+func (vm *VM) Run() error {
+	for !vm.done {
+		frame := vm.currentFrame()
+		ins := frame.Instructions()
+		if frame.ip >= len(ins) {
+			// only the program itself runs off its end, functions return
+			vm.finish(vm.stack[vm.sp-1])
+			break
+		}
+		op := code.Opcode(ins[frame.ip])
+		frame.ip++
+		if err := vm.execute(op, frame, ins); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vm *VM) finish(result object.Object) {
+	vm.result = result
+	vm.done = true
+}
+
+func (vm *VM) execute(op code.Opcode, frame *Frame, ins code.Instructions) error {
+	switch op {
+	case code.OpConstant:
+		index := code.ReadUint16(ins[frame.ip:])
+		frame.ip += 2
+		return vm.push(vm.constants[index])
+	case code.OpPop:
+		vm.pop()
+	case code.OpNull:
+		return vm.push(object.NULL)
+	case code.OpTrue:
+		return vm.push(object.TRUE)
+	case code.OpFalse:
+		return vm.push(object.FALSE)
+	case code.OpVoid:
+		return vm.push(nil)
+
+	case code.OpMinus, code.OpBang, code.OpBitNot:
+		right := vm.pop()
+		return vm.pushResult(evaluator.Prefix(operators[op], right))
+
+	case code.OpJump:
+		frame.ip = int(code.ReadUint16(ins[frame.ip:]))
+	case code.OpJumpNotTruthy:
+		target := int(code.ReadUint16(ins[frame.ip:]))
+		frame.ip += 2
+		if !evaluator.IsTruthy(vm.pop()) {
+			frame.ip = target
+		}
+
+	case code.OpGetGlobal:
+		index := code.ReadUint16(ins[frame.ip:])
+		name := code.ReadUint16(ins[frame.ip+2:])
+		frame.ip += 4
+		return vm.pushVariable(vm.globals[index], name)
+	case code.OpSetGlobal:
+		index := code.ReadUint16(ins[frame.ip:])
+		frame.ip += 2
+		vm.globals[index] = vm.pop()
+	case code.OpGetLocal:
+		index := code.ReadUint8(ins[frame.ip:])
+		frame.ip++
+		return vm.push(vm.stack[frame.basePointer+int(index)])
+	case code.OpSetLocal:
+		index := code.ReadUint8(ins[frame.ip:])
+		frame.ip++
+		vm.stack[frame.basePointer+int(index)] = vm.pop()
+	case code.OpNewCell:
+		index := code.ReadUint8(ins[frame.ip:])
+		frame.ip++
+		vm.stack[frame.basePointer+int(index)] = &object.Cell{}
+	case code.OpGetCell:
+		index := code.ReadUint8(ins[frame.ip:])
+		name := code.ReadUint16(ins[frame.ip+1:])
+		frame.ip += 3
+		return vm.pushVariable(vm.stack[frame.basePointer+int(index)].(*object.Cell).Value, name)
+	case code.OpLoadCell, code.OpSetCell:
+		index := code.ReadUint8(ins[frame.ip:])
+		frame.ip++
+		return vm.cell(op, vm.stack[frame.basePointer+int(index)].(*object.Cell))
+	case code.OpGetFree:
+		index := code.ReadUint8(ins[frame.ip:])
+		name := code.ReadUint16(ins[frame.ip+1:])
+		frame.ip += 3
+		return vm.pushVariable(frame.cl.Free[index].Value, name)
+	case code.OpLoadFree, code.OpSetFree:
+		index := code.ReadUint8(ins[frame.ip:])
+		frame.ip++
+		return vm.cell(op, frame.cl.Free[index])
+	case code.OpGetBuiltin:
+		index := code.ReadUint8(ins[frame.ip:])
+		frame.ip++
+		return vm.push(object.Builtins[index])
+
+	case code.OpArray:
+		n := int(code.ReadUint16(ins[frame.ip:]))
+		frame.ip += 2
+		elements := make([]object.Object, n)
+		copy(elements, vm.stack[vm.sp-n:vm.sp])
+		vm.sp -= n
+		return vm.push(&object.Array{Elements: elements})
+	case code.OpHash:
+		n := int(code.ReadUint16(ins[frame.ip:]))
+		frame.ip += 2
+		hash, err := vm.buildHash(vm.sp-n, vm.sp)
+		if err != nil {
+			return err
+		}
+		vm.sp -= n
+		return vm.push(hash)
+	case code.OpIndex:
+		index := vm.pop()
+		left := vm.pop()
+		return vm.pushResult(evaluator.Index(left, index))
+
+	case code.OpCall:
+		args := int(code.ReadUint8(ins[frame.ip:]))
+		frame.ip++
+		return vm.call(args)
+	case code.OpReturnValue:
+		returnValue := vm.pop()
+		if len(vm.frames) == 1 {
+			vm.finish(returnValue)
+			return nil
+		}
+		vm.frames = vm.frames[:len(vm.frames)-1]
+		vm.sp = frame.basePointer - 1
+		return vm.push(returnValue)
+	case code.OpClosure:
+		index := code.ReadUint16(ins[frame.ip:])
+		free := int(code.ReadUint8(ins[frame.ip+2:]))
+		frame.ip += 3
+		return vm.closure(int(index), free)
+
+	case code.OpIter:
+		iterable := vm.pop()
+		elements, ok := evaluator.Iterate(iterable)
+		if !ok {
+			return fmt.Errorf("cannot iterate over %s", iterable.Type())
+		}
+		return vm.push(&iterator{elements: elements})
+	case code.OpIterNext:
+		target := int(code.ReadUint16(ins[frame.ip:]))
+		frame.ip += 2
+		it := vm.stack[vm.sp-1].(*iterator)
+		if it.next >= len(it.elements) {
+			frame.ip = target
+			return nil
+		}
+		it.next++
+		return vm.push(it.elements[it.next-1])
+
+	default:
+		operator, ok := operators[op]
+		if !ok {
+			return fmt.Errorf("unknown opcode %d", op)
+		}
+		right := vm.pop()
+		left := vm.pop()
+		return vm.pushResult(evaluator.Infix(operator, left, right))
+	}
+	return nil
+}
+
+func (vm *VM) cell(op code.Opcode, cell *object.Cell) error {
+	if op == code.OpLoadCell || op == code.OpLoadFree {
+		return vm.push(cell)
+	}
+	cell.Value = vm.pop()
+	return nil
+}
+
+// pushes the value of a variable, nil when a function ran before the variable
+// was set. the error is the evaluator's, which finds no binding then
+func (vm *VM) pushVariable(value object.Object, name uint16) error {
+	if value == nil {
+		return fmt.Errorf("identifier not found: %s", vm.constants[name].(*object.String).Value)
+	}
+	return vm.push(value)
+}
+
+func (vm *VM) buildHash(start, end int) (object.Object, error) {
+	hash := object.NewHash()
+	for i := start; i < end; i += 2 {
+		key, ok := vm.stack[i].(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", vm.stack[i].Type())
+		}
+		hash.Set(key, vm.stack[i+1])
+	}
+	return hash, nil
+}
+
+func (vm *VM) call(args int) error {
+	switch callee := vm.stack[vm.sp-1-args].(type) {
+	case *object.Closure:
+		if args != callee.Fn.NumParameters {
+			return fmt.Errorf("wrong number of arguments: want=%d, got=%d", callee.Fn.NumParameters, args)
+		}
+		if len(vm.frames) == MaxFrames {
+			return errors.New("stack overflow")
+		}
+		frame := NewFrame(callee, vm.sp-args)
+		if err := vm.grow(frame.basePointer + callee.Fn.NumLocals); err != nil {
+			return err
+		}
+		vm.frames = append(vm.frames, frame)
+		vm.sp = frame.basePointer + callee.Fn.NumLocals
+		return nil
+	case *object.Builtin:
+		result := callee.Fn(vm.stack[vm.sp-args : vm.sp]...)
+		vm.sp = vm.sp - args - 1
+		return vm.pushResult(result)
+	default:
+		return fmt.Errorf("not a function: %s", callee.Type())
+	}
+}
+
+func (vm *VM) closure(index, free int) error {
+	fn, ok := vm.constants[index].(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %s", vm.constants[index].Type())
+	}
+	cells := make([]*object.Cell, free)
+	for i := 0; i < free; i++ {
+		cells[i] = vm.stack[vm.sp-free+i].(*object.Cell)
+	}
+	vm.sp -= free
+	return vm.push(&object.Closure{Fn: fn, Free: cells})
+}
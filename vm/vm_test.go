@@ -0,0 +1,105 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/eyanshu1997/yacgo/compiler"
+	"github.com/eyanshu1997/yacgo/conformance"
+	"github.com/eyanshu1997/yacgo/object"
+	"github.com/eyanshu1997/yacgo/resolver"
+	"github.com/eyanshu1997/yacgo/vm"
+)
+
+func compile(t *testing.T, input string) *compiler.Bytecode {
+	bytecode, err := conformance.Compile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytecode
+}
+
+func run(t *testing.T, input string) (object.Object, error) {
+	machine := vm.New(compile(t, input))
+	err := machine.Run()
+	return machine.Result(), err
+}
+
+// the repl runs every line on a new vm that keeps the globals and constants
+func TestGlobalsState(t *testing.T) {
+	r := resolver.NewResolver()
+	globals := make([]object.Object, vm.GlobalsSize)
+	var constants []object.Object
+	lines := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = 2;", ""},
+		{"fn double(x) { x * a }", ""},
+		{"let a = 5; double(3)", "15"},
+		{"a = a + 1; [a, double(1)]", "[6, 6]"},
+	}
+	for _, line := range lines {
+		program, err := conformance.ParseWith(r, line.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := compiler.NewWithState(constants)
+		if err := c.Compile(program); err != nil {
+			t.Fatalf("compiler error for %q: %s", line.input, err)
+		}
+		bytecode := c.Bytecode()
+		constants = bytecode.Constants
+		machine := vm.NewWithGlobalsState(bytecode, globals)
+		if err := machine.Run(); err != nil {
+			t.Fatalf("vm error for %q: %s", line.input, err)
+		}
+		got := ""
+		if machine.Result() != nil {
+			got = machine.Result().Inspect()
+		}
+		if got != line.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", line.input, line.expected, got)
+		}
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	_, err := run(t, "fn f(n) { f(n + 1) } f(0)")
+	if err == nil || err.Error() != "stack overflow" {
+		t.Errorf("unbounded recursion should overflow the stack. got=%v", err)
+	}
+}
+
+func TestClosuresInLoops(t *testing.T) {
+	result, err := run(t, `
+let fs = [];
+for i in [1, 2, 3] { let j = i * 10; fs = push(fs, fn() { j + i }); }
+[fs[0](), fs[1](), fs[2]()]`)
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	if result.Inspect() != "[11, 22, 33]" {
+		t.Errorf("every iteration should get its own variables. got=%s", result.Inspect())
+	}
+}
+
+func TestReturnFromProgram(t *testing.T) {
+	result, err := run(t, "let a = 1; return a + 1; a")
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	if result.Inspect() != "2" {
+		t.Errorf("return should stop the program. got=%s", result.Inspect())
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	machine := vm.New(compile(t, "fn f(a) {\n  let b = a + 1;\n  b / 0\n}\nf(1)"))
+	if err := machine.Run(); err == nil || err.Error() != "division by zero" {
+		t.Fatalf("expected a division by zero. got=%v", err)
+	}
+	position, ok := machine.Position()
+	if !ok || position.Line != 3 || position.Column != 3 {
+		t.Errorf("wrong position of the error. got=%+v", position)
+	}
+}